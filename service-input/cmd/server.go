@@ -2,60 +2,121 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/kameikay/service-input/configs"
 	"github.com/kameikay/service-input/internal/infra/web/controllers"
 	"github.com/kameikay/service-input/internal/infra/web/handlers"
-	"github.com/kameikay/service-input/internal/infra/web/webserver"
 	"github.com/kameikay/service-input/internal/service"
+	"github.com/kameikay/shared/configs"
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/kameikay/shared/pkg/health"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/webserver"
+	"github.com/spf13/viper"
 )
 
+// telemetryFlushTimeout bounds the export of the telemetry left in the
+// providers once the server has stopped.
+const telemetryFlushTimeout = 5 * time.Second
+
 func main() {
+	healthcheck := flag.Bool("healthcheck", false, "probe the readiness of a running server and exit")
+	flag.Parse()
+
+	// The image has no shell nor curl, so the container healthcheck runs the
+	// server binary itself.
+	if *healthcheck {
+		if err := health.HTTPCheck(http.DefaultClient, "http://localhost:8080/readyz")(context.Background()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	err := configs.LoadConfig(".")
 	if err != nil {
 		panic(err)
 	}
 
-	signChannel := make(chan os.Signal, 1)
-	signal.Notify(signChannel, os.Interrupt)
-
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	// Docker stops containers with SIGTERM.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	shutdown, err := configs.SetupOTel()
+	log := logger.New(logger.Options{
+		Format: viper.GetString("LOG_FORMAT"),
+		Level:  logger.ParseLevel(viper.GetString("LOG_LEVEL")),
+		OTel:   viper.GetBool("OTEL_LOGS_ENABLED"),
+		Name:   viper.GetString("SERVICE_NAME"),
+	})
+	slog.SetDefault(log)
+
+	shutdown, err := configs.SetupOTel(log)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 
-	defer func() {
-		if err := shutdown(context.Background()); err != nil {
-			log.Fatal("failed to shutdown tracer provider: ", err)
-		}
-	}()
+	viper.SetDefault("REQUEST_TIMEOUT", 10*time.Second)
+	viper.SetDefault("SERVER_READ_TIMEOUT", 5*time.Second)
+	viper.SetDefault("SERVER_WRITE_TIMEOUT", 15*time.Second)
+	viper.SetDefault("SERVER_IDLE_TIMEOUT", 2*time.Minute)
+	viper.SetDefault("SERVER_SHUTDOWN_TIMEOUT", 10*time.Second)
+	viper.SetDefault("HEALTH_CHECK_CACHE_TTL", health.DefaultTTL)
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT", health.DefaultTimeout)
 
 	server := webserver.NewWebServer(":8080")
+	server.RequestTimeout = viper.GetDuration("REQUEST_TIMEOUT")
+	server.ReadTimeout = viper.GetDuration("SERVER_READ_TIMEOUT")
+	server.WriteTimeout = viper.GetDuration("SERVER_WRITE_TIMEOUT")
+	server.IdleTimeout = viper.GetDuration("SERVER_IDLE_TIMEOUT")
+	server.ShutdownDelay = viper.GetDuration("SERVER_SHUTDOWN_DELAY")
+	server.Logger = log
+	server.Readiness.Timeout = viper.GetDuration("HEALTH_CHECK_TIMEOUT")
+	server.Readiness.Add("config", configs.CheckConfig)
+	server.Readiness.Add("collector", configs.CheckCollector)
+	server.Dependencies.TTL = viper.GetDuration("HEALTH_CHECK_CACHE_TTL")
+	server.Dependencies.Timeout = viper.GetDuration("HEALTH_CHECK_TIMEOUT")
 	server.MountMiddlewares()
+	server.MountProbes()
 
-	apiService := service.NewGetTemperatureService()
-	handler := handlers.NewHandler(apiService)
-	controller := controllers.NewController(server.Router, handler)
+	apiService := service.NewGetTemperatureService(log)
+	server.Dependencies.Add("service-orchestration", health.HTTPCheck(&http.Client{}, apiService.HealthURL()))
+	handler := handlers.NewHandler(log, apiService)
+	circuitBreakerHandler := webserver.NewCircuitBreakerHandler(breaker.DefaultRegistry)
+	controller := controllers.NewController(server.Router, handler, circuitBreakerHandler)
 	controller.Route()
 
+	served := make(chan error, 1)
 	go func() {
-		server.Start()
+		served <- server.Start()
 	}()
 
 	select {
-	case <-signChannel:
-		log.Println("shutting down server gracefully...")
 	case <-ctx.Done():
-		cancel()
+		log.Info("shutting down server gracefully")
+	case err := <-served:
+		log.Error("web server stopped", "error", err)
 	}
 
-	_, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Requests in flight are drained first, so that their spans are ended
+	// before the telemetry providers flush.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), viper.GetDuration("SERVER_SHUTDOWN_TIMEOUT"))
 	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to drain requests in flight", "error", err)
+	}
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+	defer flushCancel()
+
+	if err := shutdown(flushCtx); err != nil {
+		log.Error("failed to shutdown telemetry providers", "error", err)
+	}
 }
@@ -3,25 +3,31 @@ package controllers
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/kameikay/service-input/internal/infra/web/handlers"
+	"github.com/kameikay/shared/webserver"
 )
 
 type Controller struct {
-	router  chi.Router
-	Handler *handlers.Handler
+	router                chi.Router
+	Handler               *handlers.Handler
+	CircuitBreakerHandler *webserver.CircuitBreakerHandler
 }
 
 func NewController(
 	router chi.Router,
 	Handler *handlers.Handler,
+	CircuitBreakerHandler *webserver.CircuitBreakerHandler,
 ) *Controller {
 	return &Controller{
-		router:  router,
-		Handler: Handler,
+		router:                router,
+		Handler:               Handler,
+		CircuitBreakerHandler: CircuitBreakerHandler,
 	}
 }
 
 func (wc *Controller) Route() {
 	wc.router.Route("/", func(r chi.Router) {
 		r.Post("/", wc.Handler.GetTemperatures)
+		r.Post("/batch", wc.Handler.GetTemperaturesBatch)
+		r.Get("/circuit-breakers", wc.CircuitBreakerHandler.GetCircuitBreakers)
 	})
 }
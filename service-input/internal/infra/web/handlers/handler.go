@@ -2,19 +2,26 @@ package handlers
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"regexp"
+	"strings"
 
 	"github.com/kameikay/service-input/internal/service"
 	"github.com/kameikay/service-input/internal/usecase"
-	"github.com/kameikay/service-input/pkg/exceptions"
-	"github.com/kameikay/service-input/pkg/utils"
+	"github.com/kameikay/shared/pkg/batch"
+	"github.com/kameikay/shared/pkg/deadline"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/kameikay/shared/pkg/utils"
 	"github.com/spf13/viper"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Handler struct {
+	logger            *slog.Logger
 	weatherApiService service.GetTemperatureServiceInterface
 }
 
@@ -22,69 +29,54 @@ type InputDTO struct {
 	Cep string `json:"cep"`
 }
 
-func NewHandler(weatherApiService service.GetTemperatureServiceInterface) *Handler {
+func NewHandler(logger *slog.Logger, weatherApiService service.GetTemperatureServiceInterface) *Handler {
 	return &Handler{
+		logger:            logger,
 		weatherApiService: weatherApiService,
 	}
 }
 
 func (h *Handler) GetTemperatures(w http.ResponseWriter, r *http.Request) {
-	carrier := propagation.HeaderCarrier(r.Header)
-	ctx := otel.GetTextMapPropagator().Extract(r.Context(), carrier)
 	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
-
-	ctx, span := tracer.Start(ctx, "GetTemperaturesHandler")
+	ctx, span := tracer.Start(r.Context(), "GetTemperaturesHandler")
 	defer span.End()
+	deadline.Annotate(ctx, span)
+
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		ctx = service.WithCacheBypass(ctx)
+	}
 
 	var input InputDTO
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
-		utils.JsonResponse(w, utils.ResponseDTO{
-			StatusCode: http.StatusBadRequest,
-			Message:    err.Error(),
-			Success:    false,
-		})
+		telemetry.ValidationFailed(span, "body", err)
+		utils.ErrorResponse(w, r, exceptions.Wrap(exceptions.ErrInvalidRequest, err))
 		return
 	}
 
+	span.SetAttributes(telemetry.CEP(input.Cep))
+
 	if !h.validateCEP(input.Cep) {
-		utils.JsonResponse(w, utils.ResponseDTO{
-			StatusCode: http.StatusUnprocessableEntity,
-			Message:    exceptions.ErrInvalidCEP.Error(),
-			Success:    false,
-		})
+		telemetry.ValidationFailed(span, "cep", exceptions.ErrInvalidCEP)
+		utils.ErrorResponse(w, r, exceptions.ErrInvalidCEP)
 		return
 	}
 
-	getTemperaturesUseCase := usecase.NewGetTemperatureUseCase(h.weatherApiService)
+	ctx = logger.WithCEP(ctx, input.Cep)
+
+	getTemperaturesUseCase := usecase.NewGetTemperatureUseCase(h.logger, h.weatherApiService)
 	data, err := getTemperaturesUseCase.Execute(ctx, input.Cep)
 	if err != nil {
-		if err.Error() == exceptions.ErrInvalidCEP.Error() {
-			utils.JsonResponse(w, utils.ResponseDTO{
-				StatusCode: http.StatusUnprocessableEntity,
-				Message:    err.Error(),
-				Success:    false,
-			})
-			return
-		}
+		telemetry.RecordError(span, err)
+		h.logger.WarnContext(ctx, "failed to get temperatures", "error", err)
 
-		if err.Error() == exceptions.ErrCannotFindZipcode.Error() {
-			utils.JsonResponse(w, utils.ResponseDTO{
-				StatusCode: http.StatusNotFound,
-				Message:    err.Error(),
-				Success:    false,
-			})
-			return
-		}
-
-		utils.JsonResponse(w, utils.ResponseDTO{
-			StatusCode: http.StatusBadRequest,
-			Message:    err.Error(),
-			Success:    false,
-		})
+		utils.ErrorResponse(w, r, err)
 		return
 	}
 
+	span.SetAttributes(telemetry.City(data.City), telemetry.WeatherStale(data.Stale))
+	span.SetAttributes(telemetry.Temperature(data.TempC, data.TempF, data.TempK)...)
+
 	utils.JsonResponse(w, utils.ResponseDTO{
 		StatusCode: http.StatusOK,
 		Message:    http.StatusText(http.StatusOK),
@@ -93,6 +85,69 @@ func (h *Handler) GetTemperatures(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetTemperaturesBatch looks up the temperatures of every valid CEP of the
+// request in a single request to service-orchestration, which fans them out.
+// The invalid ones fail without being sent, and all the others fail alike when
+// that request does. Every CEP is traced by its own span, from its validation
+// until its result is known.
+func (h *Handler) GetTemperaturesBatch(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(r.Context(), "GetTemperaturesBatchHandler")
+	defer span.End()
+	deadline.Annotate(ctx, span)
+
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		ctx = service.WithCacheBypass(ctx)
+	}
+
+	getTemperaturesUseCase := usecase.NewGetTemperatureUseCase(h.logger, h.weatherApiService)
+	batch.Serve(w, r, span, nil, func(ceps []string) []batch.Result[usecase.Response] {
+		results := make([]batch.Result[usecase.Response], len(ceps))
+		var valid []string
+		var positions []int
+		var itemSpans []trace.Span
+		for i, cep := range ceps {
+			_, itemSpan := tracer.Start(ctx, "GetTemperaturesBatchHandler.Item", trace.WithAttributes(telemetry.CEP(cep)))
+			if !h.validateCEP(cep) {
+				telemetry.ValidationFailed(itemSpan, "cep", exceptions.ErrInvalidCEP)
+				itemSpan.End()
+				results[i].Err = exceptions.ErrInvalidCEP
+				continue
+			}
+			valid = append(valid, cep)
+			positions = append(positions, i)
+			itemSpans = append(itemSpans, itemSpan)
+		}
+		if len(valid) == 0 {
+			return results
+		}
+
+		lookedUp, err := getTemperaturesUseCase.ExecuteBatch(ctx, valid)
+		if err != nil {
+			telemetry.RecordError(span, err)
+			h.logger.WarnContext(ctx, "failed to get temperatures", "error", err)
+		}
+		for i, position := range positions {
+			if err == nil {
+				results[position] = lookedUp[i]
+			} else {
+				results[position].Err = err
+			}
+
+			itemSpan := itemSpans[i]
+			if results[position].Err != nil {
+				telemetry.RecordError(itemSpan, results[position].Err)
+			} else {
+				data := results[position].Value
+				itemSpan.SetAttributes(telemetry.City(data.City), telemetry.WeatherStale(data.Stale))
+				itemSpan.SetAttributes(telemetry.Temperature(data.TempC, data.TempF, data.TempK)...)
+			}
+			itemSpan.End()
+		}
+		return results
+	})
+}
+
 func (h *Handler) validateCEP(cep string) bool {
 	regex := regexp.MustCompile(`^\d{8}$`)
 
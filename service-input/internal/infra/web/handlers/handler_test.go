@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -13,9 +14,18 @@ import (
 	"github.com/kameikay/service-input/internal/service"
 	mock "github.com/kameikay/service-input/internal/service/mocks"
 	"github.com/kameikay/service-input/internal/usecase"
-	"github.com/kameikay/service-input/pkg/exceptions"
-	"github.com/kameikay/service-input/pkg/utils"
+	"github.com/kameikay/shared/pkg/batch"
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/kameikay/shared/pkg/utils"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type HandlerSuite struct {
@@ -40,7 +50,7 @@ func (suite *HandlerSuite) SetupTest() {
 }
 
 func (suite *HandlerSuite) TestNewHandler() {
-	handler := NewHandler(suite.getTemperatureService)
+	handler := NewHandler(logger.Discard(), suite.getTemperatureService)
 	suite.NotNil(handler)
 }
 
@@ -49,7 +59,9 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 		name             string
 		expectations     func(getTemperatureService *mock.MockGetTemperatureServiceInterface)
 		expectedResponse utils.ResponseDTO
-		requestJson      string
+		// code is the error code described in the response, if any.
+		code        exceptions.Code
+		requestJson string
 	}{
 		{
 			name: "should return correct temperatures",
@@ -75,6 +87,7 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 		},
 		{
 			name: "should return error when cep length is different from 8",
+			code: exceptions.CodeInvalidCEP,
 			expectations: func(getTemperatureService *mock.MockGetTemperatureServiceInterface) {
 				getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "123451s").Times(0)
 			},
@@ -87,6 +100,7 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 		},
 		{
 			name: "should return error when cep is invalid",
+			code: exceptions.CodeInvalidCEP,
 			expectations: func(getTemperatureService *mock.MockGetTemperatureServiceInterface) {
 				getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "123451s").Times(0)
 			},
@@ -98,19 +112,21 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 			requestJson: `{"cep":"1234567a"}`,
 		},
 		{
-			name: "should return error when there is an error getting data from services",
+			name: "should return bad gateway when an upstream call fails",
+			code: exceptions.CodeUpstreamError,
 			expectations: func(getTemperatureService *mock.MockGetTemperatureServiceInterface) {
 				getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "12345678").Return(service.GetTemperatureServiceResponse{}, errors.New("error"))
 			},
 			expectedResponse: utils.ResponseDTO{
-				StatusCode: http.StatusBadRequest,
-				Message:    "error",
+				StatusCode: http.StatusBadGateway,
+				Message:    exceptions.ErrUpstream.Error(),
 				Success:    false,
 			},
 			requestJson: `{"cep":"12345678"}`,
 		},
 		{
 			name: "should return error when cep is not found",
+			code: exceptions.CodeZipcodeNotFound,
 			expectations: func(getTemperatureService *mock.MockGetTemperatureServiceInterface) {
 				getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "12345678").Return(service.GetTemperatureServiceResponse{}, exceptions.ErrCannotFindZipcode)
 			},
@@ -123,18 +139,20 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 		},
 		{
 			name: "should return error when request is invalid",
+			code: exceptions.CodeInvalidRequest,
 			expectations: func(getTemperatureService *mock.MockGetTemperatureServiceInterface) {
 				getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "").Times(0)
 			},
 			expectedResponse: utils.ResponseDTO{
 				StatusCode: http.StatusBadRequest,
-				Message:    "error",
+				Message:    exceptions.ErrInvalidRequest.Error(),
 				Success:    false,
 			},
 			requestJson: `{"cep":123}`,
 		},
 		{
 			name: "should return error when cep is invalid",
+			code: exceptions.CodeInvalidCEP,
 			expectations: func(getTemperatureService *mock.MockGetTemperatureServiceInterface) {
 				getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "12345678").Return(service.GetTemperatureServiceResponse{}, exceptions.ErrInvalidCEP)
 			},
@@ -145,6 +163,32 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 			},
 			requestJson: `{"cep":"12345678"}`,
 		},
+		{
+			name: "should return error when the circuit breaker is open",
+			code: exceptions.CodeCircuitOpen,
+			expectations: func(getTemperatureService *mock.MockGetTemperatureServiceInterface) {
+				getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "12345678").Return(service.GetTemperatureServiceResponse{}, &breaker.OpenError{Name: "service-orchestration"})
+			},
+			expectedResponse: utils.ResponseDTO{
+				StatusCode: http.StatusServiceUnavailable,
+				Message:    exceptions.ErrCircuitOpen.Error(),
+				Success:    false,
+			},
+			requestJson: `{"cep":"12345678"}`,
+		},
+		{
+			name: "should return error when the request deadline is exceeded",
+			code: exceptions.CodeDeadlineExceeded,
+			expectations: func(getTemperatureService *mock.MockGetTemperatureServiceInterface) {
+				getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "12345678").Return(service.GetTemperatureServiceResponse{}, exceptions.ErrDeadlineExceeded)
+			},
+			expectedResponse: utils.ResponseDTO{
+				StatusCode: http.StatusGatewayTimeout,
+				Message:    exceptions.ErrDeadlineExceeded.Error(),
+				Success:    false,
+			},
+			requestJson: `{"cep":"12345678"}`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -156,7 +200,7 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 			request.Body = io.NopCloser(strings.NewReader(tc.requestJson))
 			recorder := httptest.NewRecorder()
 
-			handler := NewHandler(suite.getTemperatureService)
+			handler := NewHandler(logger.Discard(), suite.getTemperatureService)
 			handler.GetTemperatures(recorder, request)
 
 			suite.Equal(tc.expectedResponse, utils.ResponseDTO{
@@ -165,6 +209,191 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 				Success:    tc.expectedResponse.Success,
 				Data:       tc.expectedResponse.Data,
 			})
+
+			var body utils.Response
+			suite.NoError(json.Unmarshal(recorder.Body.Bytes(), &body))
+			if tc.code == "" {
+				suite.Nil(body.Error)
+			} else {
+				suite.Require().NotNil(body.Error)
+				suite.Equal(tc.code, body.Error.Code)
+				suite.Equal(recorder.Code, body.Error.Status)
+				suite.Equal(tc.expectedResponse.Message, body.Message)
+			}
 		})
 	}
 }
+
+func (suite *HandlerSuite) TestGetTemperaturesProblem() {
+	request := httptest.NewRequest(http.MethodPost, "http://test/", strings.NewReader(`{"cep":"1234567a"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", utils.ProblemContentType)
+	recorder := httptest.NewRecorder()
+
+	handler := NewHandler(logger.Discard(), suite.getTemperatureService)
+	handler.GetTemperatures(recorder, request)
+
+	suite.Equal(http.StatusUnprocessableEntity, recorder.Code)
+	suite.Equal(utils.ProblemContentType, recorder.Header().Get("Content-Type"))
+
+	var problem utils.Problem
+	suite.NoError(json.Unmarshal(recorder.Body.Bytes(), &problem))
+	suite.Equal(exceptions.CodeInvalidCEP, problem.Code)
+	suite.Equal(http.StatusUnprocessableEntity, problem.Status)
+	suite.Equal(exceptions.ErrInvalidCEP.Error(), problem.Detail)
+}
+
+func (suite *HandlerSuite) TestGetTemperaturesBatch() {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+
+	data := service.DataResponse{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293}
+	suite.getTemperatureService.EXPECT().GetTemperaturesBatch(gomock.Any(), []string{"01001000", "99999999", "01001000"}).Return([]batch.Item[service.DataResponse]{
+		{Cep: "01001000", Data: &data},
+		{Cep: "99999999", Error: exceptions.ErrCannotFindZipcode},
+		{Cep: "01001000", Data: &data},
+	}, nil).Times(1)
+
+	request := httptest.NewRequest(http.MethodPost, "http://test/batch", strings.NewReader(`{"ceps":["01001000","1234567a","99999999","01001000"]}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	handler := NewHandler(logger.Discard(), suite.getTemperatureService)
+	handler.GetTemperaturesBatch(recorder, request)
+
+	suite.Equal(http.StatusOK, recorder.Code)
+
+	var body struct {
+		Data []batch.Item[usecase.Response] `json:"data"`
+	}
+	suite.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &body))
+	suite.Require().Len(body.Data, 4)
+	expected := &usecase.Response{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293}
+	suite.Equal(batch.Item[usecase.Response]{Cep: "01001000", Data: expected}, body.Data[0])
+	suite.Equal(batch.Item[usecase.Response]{Cep: "01001000", Data: expected}, body.Data[3])
+	suite.Equal("1234567a", body.Data[1].Cep)
+	suite.Require().NotNil(body.Data[1].Error)
+	suite.Equal(exceptions.CodeInvalidCEP, body.Data[1].Error.Code)
+	suite.Equal("99999999", body.Data[2].Cep)
+	suite.Require().NotNil(body.Data[2].Error)
+	suite.Equal(exceptions.CodeZipcodeNotFound, body.Data[2].Error.Code)
+
+	var batchSpan sdktrace.ReadOnlySpan
+	var itemSpans []sdktrace.ReadOnlySpan
+	for _, span := range spans.Ended() {
+		switch span.Name() {
+		case "GetTemperaturesBatchHandler":
+			batchSpan = span
+		case "GetTemperaturesBatchHandler.Item":
+			itemSpans = append(itemSpans, span)
+		}
+	}
+	suite.Require().NotNil(batchSpan)
+	suite.Subset(batchSpan.Attributes(), append(telemetry.Batch(4, 3), telemetry.BatchFailedKey.Int(2)))
+
+	suite.Require().Len(itemSpans, 4)
+	outcomes := make(map[string][]string)
+	for _, span := range itemSpans {
+		suite.Equal(batchSpan.SpanContext().SpanID(), span.Parent().SpanID())
+		outcome := span.Status().Code.String()
+		for _, event := range span.Events() {
+			if event.Name == telemetry.ValidationFailedEvent {
+				outcome = event.Name
+			}
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == telemetry.CEPKey {
+				outcomes[attr.Value.AsString()] = append(outcomes[attr.Value.AsString()], outcome)
+			}
+		}
+	}
+	suite.Equal(map[string][]string{
+		"01001000": {codes.Unset.String(), codes.Unset.String()},
+		"1234567a": {telemetry.ValidationFailedEvent},
+		"99999999": {codes.Error.String()},
+	}, outcomes)
+}
+
+func (suite *HandlerSuite) TestGetTemperaturesBatchFailsEveryValidCEPAlike() {
+	suite.getTemperatureService.EXPECT().GetTemperaturesBatch(gomock.Any(), []string{"01001000", "20040020"}).Return(nil, exceptions.ErrDeadlineExceeded).Times(1)
+
+	recorder := httptest.NewRecorder()
+	handler := NewHandler(logger.Discard(), suite.getTemperatureService)
+	handler.GetTemperaturesBatch(recorder, httptest.NewRequest(http.MethodPost, "http://test/batch", strings.NewReader(`{"ceps":["01001000","1234567a","20040020"]}`)))
+
+	suite.Equal(http.StatusOK, recorder.Code)
+
+	var body struct {
+		Data []batch.Item[usecase.Response] `json:"data"`
+	}
+	suite.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &body))
+	suite.Require().Len(body.Data, 3)
+	for i, code := range []exceptions.Code{exceptions.CodeDeadlineExceeded, exceptions.CodeInvalidCEP, exceptions.CodeDeadlineExceeded} {
+		suite.Require().NotNil(body.Data[i].Error)
+		suite.Equal(code, body.Data[i].Error.Code)
+	}
+}
+
+func (suite *HandlerSuite) TestGetTemperaturesBatchRejectsMalformedRequests() {
+	handler := NewHandler(logger.Discard(), suite.getTemperatureService)
+
+	for _, body := range []string{`{"ceps":"01001000"}`, `{"ceps":[]}`, `{"ceps":["` + strings.Repeat(`01001000","`, 100) + `01001000"]}`} {
+		recorder := httptest.NewRecorder()
+		handler.GetTemperaturesBatch(recorder, httptest.NewRequest(http.MethodPost, "http://test/batch", strings.NewReader(body)))
+
+		suite.Equal(http.StatusBadRequest, recorder.Code)
+	}
+}
+
+func (suite *HandlerSuite) TestGetTemperaturesSpans() {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	handler := NewHandler(logger.Discard(), suite.getTemperatureService)
+	serve := func(body string) sdktrace.ReadOnlySpan {
+		recorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+		request := httptest.NewRequest(http.MethodPost, "http://test/", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		handler.GetTemperatures(httptest.NewRecorder(), request)
+
+		spans := recorder.Ended()
+		suite.Require().Len(spans, 1)
+		return spans[0]
+	}
+
+	suite.Run("describes the resolved city and temperature", func() {
+		suite.getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "01001000").Return(service.GetTemperatureServiceResponse{
+			Success: true,
+			Data:    service.DataResponse{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293},
+		}, nil)
+
+		span := serve(`{"cep":"01001000"}`)
+		suite.Equal(codes.Unset, span.Status().Code)
+		suite.Subset(span.Attributes(), []attribute.KeyValue{
+			telemetry.CEP("01001000"),
+			telemetry.City("São Paulo"),
+			telemetry.TemperatureCelsius(20),
+			telemetry.TemperatureFahrenheitKey.Float64(68),
+			telemetry.TemperatureKelvinKey.Float64(293),
+		})
+	})
+
+	suite.Run("records validation failures without failing the span", func() {
+		span := serve(`{"cep":"0100100a"}`)
+		suite.Equal(codes.Unset, span.Status().Code)
+		suite.Require().Len(span.Events(), 1)
+		suite.Equal(telemetry.ValidationFailedEvent, span.Events()[0].Name)
+		suite.Contains(span.Events()[0].Attributes, telemetry.ValidationFieldKey.String("cep"))
+	})
+
+	suite.Run("marks the span as failed on errors", func() {
+		suite.getTemperatureService.EXPECT().GetTemperatureService(gomock.Any(), "01001000").Return(service.GetTemperatureServiceResponse{}, exceptions.ErrCannotFindZipcode)
+
+		span := serve(`{"cep":"01001000"}`)
+		suite.Equal(sdktrace.Status{Code: codes.Error, Description: exceptions.ErrCannotFindZipcode.Error()}, span.Status())
+	})
+}
@@ -1,50 +0,0 @@
-package webserver
-
-import (
-	"fmt"
-	"net/http"
-
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
-)
-
-type WebServer struct {
-	Router        chi.Router
-	Handlers      []HandlerFunc
-	WebServerPort string
-}
-
-type HandlerFunc struct {
-	Method  string
-	Handler http.HandlerFunc
-}
-
-func NewWebServer(serverPort string) *WebServer {
-	return &WebServer{
-		Router:        chi.NewRouter(),
-		WebServerPort: serverPort,
-	}
-}
-
-func (s *WebServer) MountMiddlewares() {
-	// Middlewares
-	s.Router.Use(middleware.RequestID)
-	s.Router.Use(middleware.RealIP)
-	s.Router.Use(middleware.Logger)
-	s.Router.Use(middleware.Recoverer)
-	s.Router.Use(middleware.AllowContentType("application/json"))
-	s.Router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"}, // Use this to allow specific origin hosts
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300, // 5 minutes
-	}))
-}
-
-func (s *WebServer) Start() {
-	fmt.Println("Starting web server on port", s.WebServerPort)
-	http.ListenAndServe(s.WebServerPort, s.Router)
-}
@@ -1,41 +1,94 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
 
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/kameikay/shared/pkg/batch"
+	"github.com/kameikay/shared/pkg/deadline"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/httpclient"
 	"github.com/spf13/viper"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
 )
 
+type SourceResponse struct {
+	Provider string  `json:"provider"`
+	TempC    float64 `json:"temp_C"`
+	Outlier  bool    `json:"outlier"`
+	Stale    bool    `json:"stale,omitempty"`
+}
+
 type DataResponse struct {
-	City  string  `json:"city"`
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	City                  string           `json:"city"`
+	TempC                 float64          `json:"temp_C"`
+	TempF                 float64          `json:"temp_F"`
+	TempK                 float64          `json:"temp_K"`
+	Sources               []SourceResponse `json:"sources,omitempty"`
+	Stale                 bool             `json:"stale,omitempty"`
+	ObservationAgeSeconds int64            `json:"observation_age_seconds,omitempty"`
 }
 
 type GetTemperatureServiceResponse struct {
-	Success bool         `json:"success"`
-	Message string       `json:"message"`
-	Data    DataResponse `json:"data,omitempty"`
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    DataResponse      `json:"data,omitempty"`
+	Error   *exceptions.Error `json:"error,omitempty"`
+}
+
+// envelope is the answer of service-orchestration, holding data of type T
+// when it succeeds.
+type envelope[T any] struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    T                 `json:"data,omitempty"`
+	Error   *exceptions.Error `json:"error,omitempty"`
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass marks ctx so the orchestration service is asked to skip its
+// caches for this request.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
 }
 
 type GetTemperatureServiceInterface interface {
 	GetTemperatureService(ctx context.Context, cep string) (GetTemperatureServiceResponse, error)
+	GetTemperaturesBatch(ctx context.Context, ceps []string) ([]batch.Item[DataResponse], error)
 }
 
 type GetTemperatureService struct {
+	logger *slog.Logger
 	client *http.Client
 }
 
-func NewGetTemperatureService() *GetTemperatureService {
+func NewGetTemperatureService(logger *slog.Logger) *GetTemperatureService {
 	return &GetTemperatureService{
-		client: &http.Client{},
+		logger: logger,
+		client: httpclient.NewHTTPClient("service-orchestration"),
+	}
+}
+
+// HealthURL returns the readiness route of service-orchestration, probed by
+// /health/dependencies.
+func (s *GetTemperatureService) HealthURL() string {
+	return s.routeURL("readyz")
+}
+
+func (s *GetTemperatureService) routeURL(route string) string {
+	WEATHER_SERVICE_URL := viper.GetString("WEATHER_SERVICE_URL")
+	base, err := url.Parse(WEATHER_SERVICE_URL)
+	if err != nil {
+		return WEATHER_SERVICE_URL
 	}
+	return base.ResolveReference(&url.URL{Path: route}).String()
 }
 
 func (s *GetTemperatureService) GetTemperatureService(ctx context.Context, cep string) (GetTemperatureServiceResponse, error) {
@@ -47,23 +100,78 @@ func (s *GetTemperatureService) GetTemperatureService(ctx context.Context, cep s
 		return GetTemperatureServiceResponse{}, err
 	}
 
-	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	response, err := send[DataResponse](ctx, s, req)
+	if err != nil {
+		return GetTemperatureServiceResponse{}, err
+	}
+
+	return GetTemperatureServiceResponse(response), nil
+}
+
+// GetTemperaturesBatch looks up every CEP of ceps on the batch route of
+// service-orchestration, in a single request, and returns one item per CEP in
+// the same order.
+func (s *GetTemperatureService) GetTemperaturesBatch(ctx context.Context, ceps []string) ([]batch.Item[DataResponse], error) {
+	body, err := json.Marshal(batch.Request{Ceps: ceps})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.routeURL("batch"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := send[[]batch.Item[DataResponse]](ctx, s, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Data) != len(ceps) {
+		return nil, exceptions.Wrap(exceptions.ErrUpstream, fmt.Errorf("%d items answered for %d ceps", len(response.Data), len(ceps)))
+	}
+
+	return response.Data, nil
+}
+
+// send sends req to service-orchestration along with the deadline, request
+// ID and cache bypass of ctx, and rebuilds the error it answers with.
+func send[T any](ctx context.Context, s *GetTemperatureService, req *http.Request) (envelope[T], error) {
+	deadline.Inject(ctx, req.Header)
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		req.Header.Set(middleware.RequestIDHeader, requestID)
+	}
+	if bypass, _ := ctx.Value(cacheBypassKey{}).(bool); bypass {
+		req.Header.Set("Cache-Control", "no-cache")
+	}
 
 	res, err := s.client.Do(req)
 	if err != nil {
-		return GetTemperatureServiceResponse{}, err
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return envelope[T]{}, exceptions.ErrDeadlineExceeded
+		}
+		return envelope[T]{}, httpclient.Redact(err)
 	}
 
 	defer res.Body.Close()
 
-	var response GetTemperatureServiceResponse
+	var response envelope[T]
 	err = json.NewDecoder(res.Body).Decode(&response)
 	if err != nil {
-		return GetTemperatureServiceResponse{}, err
+		// Proxies in front of service-orchestration answer without the
+		// envelope.
+		if res.StatusCode != http.StatusOK {
+			return envelope[T]{}, exceptions.FromStatus(res.StatusCode, "")
+		}
+		return envelope[T]{}, exceptions.Wrap(exceptions.ErrUpstream, err)
 	}
 
 	if !response.Success {
-		return GetTemperatureServiceResponse{}, errors.New(response.Message)
+		s.logger.DebugContext(ctx, "service-orchestration rejected the request", "status", res.StatusCode, "message", response.Message)
+		if response.Error != nil {
+			return envelope[T]{}, response.Error
+		}
+		return envelope[T]{}, exceptions.FromStatus(res.StatusCode, response.Message)
 	}
 
 	return response, nil
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kameikay/shared/pkg/batch"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/pkg/utils"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetTemperatureServiceSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func TestGetTemperatureServiceStart(t *testing.T) {
+	suite.Run(t, new(GetTemperatureServiceSuite))
+}
+
+func (suite *GetTemperatureServiceSuite) SetupTest() {
+	suite.ctx = context.Background()
+	viper.Set("RETRY_MAX_ATTEMPTS", 1)
+}
+
+func (suite *GetTemperatureServiceSuite) TearDownTest() {
+	viper.Reset()
+}
+
+func (suite *GetTemperatureServiceSuite) serve(handler http.HandlerFunc) *GetTemperatureService {
+	server := httptest.NewServer(handler)
+	suite.T().Cleanup(server.Close)
+	viper.Set("WEATHER_SERVICE_URL", server.URL+"/")
+
+	return NewGetTemperatureService(logger.Discard())
+}
+
+func (suite *GetTemperatureServiceSuite) TestRebuildsOrchestrationErrors() {
+	for _, expected := range []*exceptions.Error{
+		exceptions.ErrCannotFindZipcode,
+		exceptions.ErrCEPServiceUnavailable,
+		exceptions.Wrap(exceptions.ErrWeatherServiceUnavailable, exceptions.ErrCannotFindWeatherData),
+	} {
+		service := suite.serve(func(w http.ResponseWriter, r *http.Request) {
+			utils.ErrorResponse(w, r, expected)
+		})
+
+		_, err := service.GetTemperatureService(suite.ctx, "01001000")
+
+		suite.ErrorIs(err, expected)
+		rebuilt := exceptions.From(err)
+		suite.Equal(expected.Status, rebuilt.Status)
+		suite.Equal(expected.Retryable, rebuilt.Retryable)
+		suite.Equal(expected.Message, rebuilt.Error())
+	}
+}
+
+func (suite *GetTemperatureServiceSuite) TestMapsResponsesWithoutError() {
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		expected *exceptions.Error
+	}{
+		{name: "gateway timeout from a proxy", status: http.StatusGatewayTimeout, body: "<html>", expected: exceptions.ErrDeadlineExceeded},
+		{name: "bad gateway from a proxy", status: http.StatusBadGateway, body: "<html>", expected: exceptions.ErrUpstream},
+		{name: "malformed success", status: http.StatusOK, body: "<html>", expected: exceptions.ErrUpstream},
+		{name: "envelope without error", status: http.StatusNotFound, body: `{"success":false,"message":"not found"}`, expected: exceptions.ErrUpstream},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			service := suite.serve(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			})
+
+			_, err := service.GetTemperatureService(suite.ctx, "01001000")
+			suite.ErrorIs(err, tc.expected)
+		})
+	}
+}
+
+func (suite *GetTemperatureServiceSuite) TestGetTemperaturesBatch() {
+	data := DataResponse{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293}
+	var requests int
+	service := suite.serve(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		suite.Equal(http.MethodPost, r.Method)
+		suite.Equal("/batch", r.URL.Path)
+
+		var input batch.Request
+		suite.Require().NoError(json.NewDecoder(r.Body).Decode(&input))
+		suite.Equal([]string{"01001000", "99999999"}, input.Ceps)
+
+		utils.JsonResponse(w, utils.ResponseDTO{
+			StatusCode: http.StatusOK,
+			Success:    true,
+			Data: []batch.Item[DataResponse]{
+				{Cep: "01001000", Data: &data},
+				{Cep: "99999999", Error: exceptions.ErrCannotFindZipcode},
+			},
+		})
+	})
+
+	items, err := service.GetTemperaturesBatch(suite.ctx, []string{"01001000", "99999999"})
+
+	suite.Require().NoError(err)
+	suite.Equal(1, requests, "the batch is sent in a single request")
+	suite.Require().Len(items, 2)
+	suite.Equal(batch.Item[DataResponse]{Cep: "01001000", Data: &data}, items[0])
+	suite.Require().NotNil(items[1].Error)
+	suite.ErrorIs(items[1].Error, exceptions.ErrCannotFindZipcode)
+}
+
+func (suite *GetTemperatureServiceSuite) TestGetTemperaturesBatchFailures() {
+	suite.Run("batch rejected", func() {
+		service := suite.serve(func(w http.ResponseWriter, r *http.Request) {
+			utils.ErrorResponse(w, r, exceptions.ErrInvalidRequest)
+		})
+
+		_, err := service.GetTemperaturesBatch(suite.ctx, []string{"01001000"})
+		suite.ErrorIs(err, exceptions.ErrInvalidRequest)
+	})
+
+	suite.Run("items missing", func() {
+		service := suite.serve(func(w http.ResponseWriter, r *http.Request) {
+			utils.JsonResponse(w, utils.ResponseDTO{StatusCode: http.StatusOK, Success: true, Data: []batch.Item[DataResponse]{}})
+		})
+
+		_, err := service.GetTemperaturesBatch(suite.ctx, []string{"01001000"})
+		suite.ErrorIs(err, exceptions.ErrUpstream)
+	})
+}
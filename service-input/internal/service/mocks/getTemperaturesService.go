@@ -10,6 +10,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	service "github.com/kameikay/service-input/internal/service"
+	batch "github.com/kameikay/shared/pkg/batch"
 )
 
 // MockGetTemperatureServiceInterface is a mock of GetTemperatureServiceInterface interface.
@@ -49,3 +50,18 @@ func (mr *MockGetTemperatureServiceInterfaceMockRecorder) GetTemperatureService(
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemperatureService", reflect.TypeOf((*MockGetTemperatureServiceInterface)(nil).GetTemperatureService), ctx, cep)
 }
+
+// GetTemperaturesBatch mocks base method.
+func (m *MockGetTemperatureServiceInterface) GetTemperaturesBatch(ctx context.Context, ceps []string) ([]batch.Item[service.DataResponse], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemperaturesBatch", ctx, ceps)
+	ret0, _ := ret[0].([]batch.Item[service.DataResponse])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemperaturesBatch indicates an expected call of GetTemperaturesBatch.
+func (mr *MockGetTemperatureServiceInterfaceMockRecorder) GetTemperaturesBatch(ctx, ceps interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemperaturesBatch", reflect.TypeOf((*MockGetTemperatureServiceInterface)(nil).GetTemperaturesBatch), ctx, ceps)
+}
@@ -2,23 +2,34 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 
 	"github.com/kameikay/service-input/internal/service"
+	"github.com/kameikay/shared/pkg/batch"
+	"github.com/kameikay/shared/pkg/exceptions"
 )
 
+var errItemWithoutData = errors.New("batch item without data")
+
 type GetTemperaturesUseCase struct {
+	logger            *slog.Logger
 	weatherApiService service.GetTemperatureServiceInterface
 }
 
 type Response struct {
-	City  string  `json:"city"`
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	City                  string                   `json:"city"`
+	TempC                 float64                  `json:"temp_C"`
+	TempF                 float64                  `json:"temp_F"`
+	TempK                 float64                  `json:"temp_K"`
+	Sources               []service.SourceResponse `json:"sources,omitempty"`
+	Stale                 bool                     `json:"stale,omitempty"`
+	ObservationAgeSeconds int64                    `json:"observation_age_seconds,omitempty"`
 }
 
-func NewGetTemperatureUseCase(weatherApiService service.GetTemperatureServiceInterface) *GetTemperaturesUseCase {
+func NewGetTemperatureUseCase(logger *slog.Logger, weatherApiService service.GetTemperatureServiceInterface) *GetTemperaturesUseCase {
 	return &GetTemperaturesUseCase{
+		logger:            logger,
 		weatherApiService: weatherApiService,
 	}
 }
@@ -29,11 +40,43 @@ func (u *GetTemperaturesUseCase) Execute(ctx context.Context, cep string) (Respo
 		return Response{}, err
 	}
 
-	return Response{
-		City:  weatherData.Data.City,
-		TempC: weatherData.Data.TempC,
-		TempF: weatherData.Data.TempF,
-		TempK: weatherData.Data.TempK,
-	}, nil
+	u.logger.DebugContext(ctx, "temperature resolved", "city", weatherData.Data.City, "temp_c", weatherData.Data.TempC, "stale", weatherData.Data.Stale)
 
+	return newResponse(weatherData.Data), nil
+
+}
+
+// ExecuteBatch looks up every CEP of ceps in a single request to
+// service-orchestration and returns their results in the same order. It only
+// fails when that request does.
+func (u *GetTemperaturesUseCase) ExecuteBatch(ctx context.Context, ceps []string) ([]batch.Result[Response], error) {
+	items, err := u.weatherApiService.GetTemperaturesBatch(ctx, ceps)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]batch.Result[Response], len(items))
+	for i, item := range items {
+		switch {
+		case item.Error != nil:
+			results[i].Err = item.Error
+		case item.Data == nil:
+			results[i].Err = exceptions.Wrap(exceptions.ErrUpstream, errItemWithoutData)
+		default:
+			results[i].Value = newResponse(*item.Data)
+		}
+	}
+	return results, nil
+}
+
+func newResponse(data service.DataResponse) Response {
+	return Response{
+		City:                  data.City,
+		TempC:                 data.TempC,
+		TempF:                 data.TempF,
+		TempK:                 data.TempK,
+		Sources:               data.Sources,
+		Stale:                 data.Stale,
+		ObservationAgeSeconds: data.ObservationAgeSeconds,
+	}
 }
@@ -2,62 +2,224 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/kameikay/service-orchestration/configs"
+	"github.com/kameikay/service-orchestration/internal/cache"
 	"github.com/kameikay/service-orchestration/internal/infra/web/controllers"
 	"github.com/kameikay/service-orchestration/internal/infra/web/handlers"
-	"github.com/kameikay/service-orchestration/internal/infra/web/webserver"
 	"github.com/kameikay/service-orchestration/internal/service"
+	"github.com/kameikay/shared/configs"
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/kameikay/shared/pkg/health"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/webserver"
+	"github.com/spf13/viper"
 )
 
+// telemetryFlushTimeout bounds the export of the telemetry left in the
+// providers once the server has stopped.
+const telemetryFlushTimeout = 5 * time.Second
+
 func main() {
+	healthcheck := flag.Bool("healthcheck", false, "probe the readiness of a running server and exit")
+	flag.Parse()
+
+	// The image has no shell nor curl, so the container healthcheck runs the
+	// server binary itself.
+	if *healthcheck {
+		if err := health.HTTPCheck(http.DefaultClient, "http://localhost:8081/readyz")(context.Background()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	err := configs.LoadConfig(".")
 	if err != nil {
 		panic(err)
 	}
 
-	signChannel := make(chan os.Signal, 1)
-	signal.Notify(signChannel, os.Interrupt)
-
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	// Docker stops containers with SIGTERM.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	shutdown, err := configs.SetupOTel()
+	log := logger.New(logger.Options{
+		Format: viper.GetString("LOG_FORMAT"),
+		Level:  logger.ParseLevel(viper.GetString("LOG_LEVEL")),
+		OTel:   viper.GetBool("OTEL_LOGS_ENABLED"),
+		Name:   viper.GetString("SERVICE_NAME"),
+	})
+	slog.SetDefault(log)
+
+	shutdown, err := configs.SetupOTel(log)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 
-	defer func() {
-		if err := shutdown(ctx); err != nil {
-			log.Fatal("failed to shutdown tracer provider: ", err)
-		}
-	}()
+	err = run(ctx, log)
+	if err != nil {
+		log.Error("failed to start server", "error", err)
+	}
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+	defer flushCancel()
+
+	if err := shutdown(flushCtx); err != nil {
+		log.Error("failed to shutdown telemetry providers", "error", err)
+	}
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// run serves requests until ctx is done, then drains those in flight. It
+// returns the configuration errors found at startup, so that main flushes the
+// telemetry describing them before exiting.
+func run(ctx context.Context, log *slog.Logger) error {
+	viper.SetDefault("REQUEST_TIMEOUT", 10*time.Second)
+	viper.SetDefault("SERVER_READ_TIMEOUT", 5*time.Second)
+	viper.SetDefault("SERVER_WRITE_TIMEOUT", 15*time.Second)
+	viper.SetDefault("SERVER_IDLE_TIMEOUT", 2*time.Minute)
+	viper.SetDefault("SERVER_SHUTDOWN_TIMEOUT", 10*time.Second)
+	viper.SetDefault("HEALTH_CHECK_CACHE_TTL", health.DefaultTTL)
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT", health.DefaultTimeout)
 
 	server := webserver.NewWebServer(":8081")
+	server.RequestTimeout = viper.GetDuration("REQUEST_TIMEOUT")
+	server.ReadTimeout = viper.GetDuration("SERVER_READ_TIMEOUT")
+	server.WriteTimeout = viper.GetDuration("SERVER_WRITE_TIMEOUT")
+	server.IdleTimeout = viper.GetDuration("SERVER_IDLE_TIMEOUT")
+	server.ShutdownDelay = viper.GetDuration("SERVER_SHUTDOWN_DELAY")
+	server.Logger = log
+	server.Readiness.Timeout = viper.GetDuration("HEALTH_CHECK_TIMEOUT")
+	server.Readiness.Add("config", configs.CheckConfig)
+	server.Readiness.Add("collector", configs.CheckCollector)
+	server.Dependencies.TTL = viper.GetDuration("HEALTH_CHECK_CACHE_TTL")
+	server.Dependencies.Timeout = viper.GetDuration("HEALTH_CHECK_TIMEOUT")
 
 	server.MountMiddlewares()
+	server.MountProbes()
+
+	cepProviders, err := service.NewCEPProviders(service.ParseProviderNames(viper.GetString("CEP_PROVIDERS"))...)
+	if err != nil {
+		return fmt.Errorf("failed to build cep providers: %w", err)
+	}
 
-	viaCepService := service.NewViaCepService()
-	weatherApiService := service.NewWeatherApiService()
-	handler := handlers.NewHandler(viaCepService, weatherApiService)
-	controller := controllers.NewController(server.Router, handler)
+	// The dependencies are probed without the breakers and retries of the
+	// service clients, so the report shows the APIs as they are.
+	probeClient := &http.Client{}
+	for _, provider := range cepProviders {
+		if prober, ok := provider.(service.HealthProber); ok {
+			server.Dependencies.Add(provider.Name(), health.HTTPCheck(probeClient, prober.HealthURL()))
+		}
+	}
+
+	viper.SetDefault("CACHE_MAX_ENTRIES", cache.DefaultMaxEntries)
+	viper.SetDefault("CACHE_CEP_TTL", 24*time.Hour)
+	viper.SetDefault("CACHE_WEATHER_TTL", 5*time.Minute)
+	viper.SetDefault("CACHE_WEATHER_MAX_STALENESS", time.Hour)
+	viper.SetDefault("CACHE_NAMESPACE", viper.GetString("SERVICE_NAME"))
+	cacheEnabled := viper.GetBool("CACHE_ENABLED")
+
+	var redisClient *cache.RedisClient
+	if cacheEnabled && viper.GetString("CACHE_BACKEND") == "redis" {
+		redisClient = cache.NewRedisClient(cache.RedisOptions{
+			Addr:     viper.GetString("REDIS_ADDR"),
+			Password: viper.GetString("REDIS_PASSWORD"),
+			DB:       viper.GetInt("REDIS_DB"),
+			Timeout:  viper.GetDuration("REDIS_TIMEOUT"),
+		})
+		defer redisClient.Close()
+	}
+
+	var viaCepService service.ViaCepServiceInterface = service.NewCoalescedViaCepService(service.NewViaCepService(log, cepProviders...))
+	if cacheEnabled {
+		viaCepService = service.NewCachedViaCepService(
+			viaCepService,
+			newStore[service.ViaCEPResponse](redisClient, "cep", viper.GetDuration("CACHE_CEP_TTL")),
+			cache.NewMetrics("cep"),
+		)
+	}
+
+	// In consensus mode every provider in WEATHER_PROVIDERS is queried and the
+	// readings are cross-checked, otherwise only WEATHER_PROVIDER is used.
+	viper.SetDefault("WEATHER_PROVIDER", service.WeatherProviderWeatherAPI)
+	weatherProviders := []string{viper.GetString("WEATHER_PROVIDER")}
+	if viper.GetString("WEATHER_MODE") == "consensus" {
+		weatherProviders = service.ParseProviderNames(viper.GetString("WEATHER_PROVIDERS"))
+	}
+	if len(weatherProviders) == 0 {
+		return fmt.Errorf("no weather provider configured in %q mode", viper.GetString("WEATHER_MODE"))
+	}
+
+	var weatherApiServices []service.WeatherApiServiceInterface
+	for _, name := range weatherProviders {
+		weatherApiService, err := service.NewWeatherProvider(name)
+		if err != nil {
+			return fmt.Errorf("failed to build weather provider: %w", err)
+		}
+		if prober, ok := weatherApiService.(service.HealthProber); ok {
+			server.Dependencies.Add(name, health.HTTPCheck(probeClient, prober.HealthURL()))
+		}
+		weatherApiService = service.NewCoalescedWeatherApiService(weatherApiService)
+		if cacheEnabled {
+			weatherTTL := viper.GetDuration("CACHE_WEATHER_TTL")
+			maxStale := viper.GetDuration("CACHE_WEATHER_MAX_STALENESS")
+			weatherApiService = service.NewCachedWeatherApiService(
+				log,
+				weatherApiService,
+				newStore[service.WeatherCacheEntry](redisClient, "weather:"+name, weatherTTL+maxStale),
+				cache.NewMetrics("weather:"+name),
+				weatherTTL,
+				maxStale,
+			)
+		}
+		weatherApiServices = append(weatherApiServices, weatherApiService)
+	}
+
+	handler := handlers.NewHandler(log, viaCepService, weatherApiServices...)
+	circuitBreakerHandler := webserver.NewCircuitBreakerHandler(breaker.DefaultRegistry)
+	controller := controllers.NewController(server.Router, handler, circuitBreakerHandler)
 	controller.Route()
 
+	served := make(chan error, 1)
 	go func() {
-		server.Start()
+		served <- server.Start()
 	}()
 
 	select {
-	case <-signChannel:
-		log.Println("shutting down server gracefully...")
 	case <-ctx.Done():
-		log.Println("shutting down server...")
+		log.Info("shutting down server gracefully")
+	case err := <-served:
+		log.Error("web server stopped", "error", err)
 	}
 
-	_, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Requests in flight are drained first, so that their spans are ended
+	// before the telemetry providers flush.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), viper.GetDuration("SERVER_SHUTDOWN_TIMEOUT"))
 	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to drain requests in flight", "error", err)
+	}
+
+	return nil
+}
+
+// newStore returns a Redis backed store when a client is configured, so that
+// replicas share their results, and an in-process LRU otherwise.
+func newStore[V any](redisClient *cache.RedisClient, namespace string, ttl time.Duration) cache.Store[V] {
+	if redisClient != nil {
+		return cache.NewRedisStore[V](redisClient, viper.GetString("CACHE_NAMESPACE")+":"+namespace, ttl)
+	}
+
+	return cache.NewLRU[V](viper.GetInt("CACHE_MAX_ENTRIES"), ttl)
 }
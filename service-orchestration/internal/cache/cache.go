@@ -0,0 +1,23 @@
+package cache
+
+import "context"
+
+// Store is a best-effort key/value cache. A lookup that fails for any reason
+// is reported as a miss so callers can always fall back to the upstream.
+type Store[V any] interface {
+	Get(ctx context.Context, key string) (V, bool)
+	Set(ctx context.Context, key string, value V)
+}
+
+type bypassKey struct{}
+
+// WithBypass marks ctx so cached services skip their lookups and go straight
+// to the upstream. Fresh results are still written back.
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func IsBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const DefaultMaxEntries = 1000
+
+type lruEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// LRU is an in-process Store bounded to a fixed number of entries, evicting
+// the least recently used one when full. Entries expire after ttl.
+type LRU[V any] struct {
+	mu         sync.Mutex
+	entries    *list.List
+	items      map[string]*list.Element
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+}
+
+func NewLRU[V any](maxEntries int, ttl time.Duration) *LRU[V] {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return &LRU[V]{
+		entries:    list.New(),
+		items:      make(map[string]*list.Element),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+	}
+}
+
+func (c *LRU[V]) Get(_ context.Context, key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := element.Value.(*lruEntry[V])
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		return zero, false
+	}
+
+	c.entries.MoveToFront(element)
+	return entry.value, true
+}
+
+func (c *LRU[V]) Set(_ context.Context, key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*lruEntry[V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.entries.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.entries.PushFront(&lruEntry[V]{key: key, value: value, expiresAt: expiresAt})
+	if c.entries.Len() > c.maxEntries {
+		c.remove(c.entries.Back())
+	}
+}
+
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+func (c *LRU[V]) remove(element *list.Element) {
+	c.entries.Remove(element)
+	delete(c.items, element.Value.(*lruEntry[V]).key)
+}
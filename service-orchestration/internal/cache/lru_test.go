@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUGetSet(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU[string](2, time.Minute)
+
+	_, ok := lru.Get(ctx, "a")
+	assert.False(t, ok)
+
+	lru.Set(ctx, "a", "1")
+	value, ok := lru.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	lru.Set(ctx, "a", "2")
+	value, _ = lru.Get(ctx, "a")
+	assert.Equal(t, "2", value)
+	assert.Equal(t, 1, lru.Len())
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU[int](2, time.Minute)
+
+	lru.Set(ctx, "a", 1)
+	lru.Set(ctx, "b", 2)
+	lru.Get(ctx, "a")
+	lru.Set(ctx, "c", 3)
+
+	_, ok := lru.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok = lru.Get(ctx, "a")
+	assert.True(t, ok)
+	_, ok = lru.Get(ctx, "c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	lru := NewLRU[int](2, time.Minute)
+	lru.now = func() time.Time { return now }
+
+	lru.Set(ctx, "a", 1)
+	now = now.Add(time.Minute)
+
+	_, ok := lru.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, lru.Len())
+}
+
+func TestBypass(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, IsBypassed(ctx))
+	assert.True(t, IsBypassed(WithBypass(ctx)))
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Lookup outcomes recorded by Metrics.
+const (
+	ResultHit    = "hit"
+	ResultStale  = "stale"
+	ResultMiss   = "miss"
+	ResultBypass = "bypass"
+)
+
+// Metrics counts the lookups of a cache by outcome and reports its hit ratio,
+// stale entries counting as hits and bypassed lookups left out.
+type Metrics struct {
+	name    attribute.KeyValue
+	lookups metric.Int64Counter
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+func NewMetrics(name string) *Metrics {
+	meter := otel.Meter(viper.GetString("SERVICE_NAME"))
+	m := &Metrics{name: attribute.String("cache.name", name)}
+
+	var err error
+	m.lookups, err = meter.Int64Counter("cache.lookups",
+		metric.WithDescription("Cache lookups by outcome."),
+		metric.WithUnit("{lookup}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	_, err = meter.Float64ObservableGauge("cache.hit_ratio",
+		metric.WithDescription("Share of cache lookups served from the cache since startup."),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			if ratio, ok := m.HitRatio(); ok {
+				o.Observe(ratio, metric.WithAttributes(m.name))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return m
+}
+
+// Record counts a lookup with one of the Result outcomes.
+func (m *Metrics) Record(ctx context.Context, result string) {
+	switch result {
+	case ResultHit, ResultStale:
+		m.hits.Add(1)
+	case ResultMiss:
+		m.misses.Add(1)
+	}
+	m.lookups.Add(ctx, 1, metric.WithAttributes(m.name, attribute.String("cache.result", result)))
+}
+
+// HitRatio returns the share of lookups served from the cache, if any lookup
+// was recorded.
+func (m *Metrics) HitRatio() (float64, bool) {
+	hits, misses := m.hits.Load(), m.misses.Load()
+	if hits+misses == 0 {
+		return 0, false
+	}
+	return float64(hits) / float64(hits+misses), true
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisStore is a Store shared between replicas through a Redis compatible
+// server. Values are stored as JSON under namespace-prefixed keys and expire
+// after ttl. Server errors are recorded on the current span and reported as
+// misses.
+type RedisStore[V any] struct {
+	client    *RedisClient
+	namespace string
+	ttl       time.Duration
+}
+
+func NewRedisStore[V any](client *RedisClient, namespace string, ttl time.Duration) *RedisStore[V] {
+	return &RedisStore[V]{
+		client:    client,
+		namespace: namespace,
+		ttl:       ttl,
+	}
+}
+
+func (s *RedisStore[V]) Get(ctx context.Context, key string) (V, bool) {
+	var value V
+
+	reply, err := s.client.Do(ctx, "GET", s.key(key))
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		return value, false
+	}
+
+	data, ok := reply.(string)
+	if !ok {
+		return value, false
+	}
+
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		return value, false
+	}
+
+	return value, true
+}
+
+func (s *RedisStore[V]) Set(ctx context.Context, key string, value V) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		return
+	}
+
+	args := []string{"SET", s.key(key), string(data)}
+	if s.ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(s.ttl.Milliseconds(), 10))
+	}
+
+	if _, err := s.client.Do(ctx, args...); err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+	}
+}
+
+func (s *RedisStore[V]) key(key string) string {
+	return s.namespace + ":" + key
+}
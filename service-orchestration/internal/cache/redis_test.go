@@ -0,0 +1,155 @@
+package cache_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kameikay/service-orchestration/internal/cache"
+	"github.com/kameikay/service-orchestration/internal/cache/redistest"
+	"github.com/kameikay/service-orchestration/internal/service"
+	"github.com/stretchr/testify/suite"
+)
+
+type payload struct {
+	City  string  `json:"city"`
+	TempC float64 `json:"temp_c"`
+}
+
+type RedisStoreSuite struct {
+	suite.Suite
+	server *redistest.Server
+	client *cache.RedisClient
+	ctx    context.Context
+}
+
+func TestRedisStoreStart(t *testing.T) {
+	suite.Run(t, new(RedisStoreSuite))
+}
+
+func (suite *RedisStoreSuite) SetupTest() {
+	server, err := redistest.NewServer("secret")
+	suite.Require().NoError(err)
+	suite.server = server
+	suite.client = cache.NewRedisClient(cache.RedisOptions{Addr: server.Addr(), Password: "secret", DB: 1})
+	suite.ctx = context.Background()
+}
+
+func (suite *RedisStoreSuite) TearDownTest() {
+	suite.client.Close()
+	suite.server.Close()
+}
+
+func (suite *RedisStoreSuite) TestPing() {
+	suite.NoError(suite.client.Ping(suite.ctx))
+
+	client := cache.NewRedisClient(cache.RedisOptions{Addr: suite.server.Addr(), Password: "wrong"})
+	suite.Error(client.Ping(suite.ctx))
+}
+
+func (suite *RedisStoreSuite) TestGetSet() {
+	store := cache.NewRedisStore[payload](suite.client, "test:weather", time.Minute)
+
+	_, ok := store.Get(suite.ctx, "são paulo")
+	suite.False(ok)
+
+	store.Set(suite.ctx, "são paulo", payload{City: "São Paulo", TempC: 21.5})
+	value, ok := store.Get(suite.ctx, "são paulo")
+	suite.True(ok)
+	suite.Equal(payload{City: "São Paulo", TempC: 21.5}, value)
+
+	suite.Equal([]string{"test:weather:são paulo"}, suite.server.Keys())
+	suite.Contains(suite.server.Commands(), `SET test:weather:são paulo {"city":"São Paulo","temp_c":21.5} PX 60000`)
+}
+
+func (suite *RedisStoreSuite) TestCEPRoundTrip() {
+	store := cache.NewRedisStore[service.ViaCEPResponse](suite.client, "test:cep", time.Minute)
+	address := service.ViaCEPResponse{
+		Cep:        "01001-000",
+		Logradouro: "Praça da Sé",
+		Bairro:     "Sé",
+		Localidade: "São Paulo",
+		Uf:         "SP",
+		Ibge:       "3550308",
+		Ddd:        "11",
+		Provider:   "viacep",
+	}
+
+	store.Set(suite.ctx, "01001-000", address)
+	value, ok := store.Get(suite.ctx, "01001-000")
+	suite.True(ok)
+	suite.Equal(address, value)
+	suite.Contains(suite.server.Commands(), `SET test:cep:01001-000 {"erro":"","cep":"01001-000","logradouro":"Praça da Sé","complemento":"","bairro":"Sé","localidade":"São Paulo","uf":"SP","ibge":"3550308","gia":"","ddd":"11","siafi":"","provider":"viacep"} PX 60000`)
+}
+
+func (suite *RedisStoreSuite) TestWeatherRoundTrip() {
+	store := cache.NewRedisStore[service.WeatherCacheEntry](suite.client, "test:weather", time.Minute)
+	entry := service.WeatherCacheEntry{
+		Observation: service.WeatherObservation{
+			Provider:   "weatherapi",
+			Location:   "São Paulo",
+			TempC:      21.5,
+			ObservedAt: time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC),
+			Stale:      true,
+		},
+		FetchedAt: time.Date(2024, time.March, 1, 12, 45, 0, 0, time.UTC),
+	}
+
+	store.Set(suite.ctx, "são paulo", entry)
+	value, ok := store.Get(suite.ctx, "são paulo")
+	suite.True(ok)
+	suite.Equal(entry, value)
+	suite.True(value.Observation.ObservedAt.Equal(entry.Observation.ObservedAt))
+	suite.Contains(suite.server.Commands(), `SET test:weather:são paulo {"observation":{"provider":"weatherapi","location":"São Paulo","temp_c":21.5,"observed_at":"2024-03-01T12:30:00Z","stale":true},"fetched_at":"2024-03-01T12:45:00Z"} PX 60000`)
+}
+
+func (suite *RedisStoreSuite) TestNamespacesAreIsolated() {
+	cep := cache.NewRedisStore[payload](suite.client, "test:cep", time.Minute)
+	weather := cache.NewRedisStore[payload](suite.client, "test:weather", time.Minute)
+
+	cep.Set(suite.ctx, "key", payload{City: "cep"})
+	_, ok := weather.Get(suite.ctx, "key")
+	suite.False(ok)
+}
+
+func (suite *RedisStoreSuite) TestExpiration() {
+	store := cache.NewRedisStore[payload](suite.client, "test", time.Minute)
+	store.Set(suite.ctx, "key", payload{City: "São Paulo"})
+
+	suite.server.Advance(time.Minute)
+	_, ok := store.Get(suite.ctx, "key")
+	suite.False(ok)
+}
+
+func (suite *RedisStoreSuite) TestServerUnavailableIsAMiss() {
+	store := cache.NewRedisStore[payload](suite.client, "test", time.Minute)
+	suite.server.Close()
+
+	store.Set(suite.ctx, "key", payload{City: "São Paulo"})
+	_, ok := store.Get(suite.ctx, "key")
+	suite.False(ok)
+}
+
+func (suite *RedisStoreSuite) TestStalledServerTimesOut() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	defer listener.Close()
+	go func() {
+		// Accept connections and never answer.
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	client := cache.NewRedisClient(cache.RedisOptions{Addr: listener.Addr().String(), Timeout: 50 * time.Millisecond})
+	defer client.Close()
+
+	start := time.Now()
+	suite.Error(client.Ping(suite.ctx))
+	suite.Less(time.Since(start), time.Second)
+}
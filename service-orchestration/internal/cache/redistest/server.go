@@ -0,0 +1,256 @@
+// Package redistest provides an in-process server speaking enough of the
+// Redis protocol to exercise cache.RedisStore without a real Redis.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kameikay/service-orchestration/internal/cache"
+)
+
+type entry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// Server is a fake RESP server listening on a loopback address. It supports
+// PING, AUTH, SELECT, GET, SET (with EX and PX), DEL, TTL and FLUSHALL.
+type Server struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	data     map[string]entry
+	now      func() time.Time
+	conns    map[net.Conn]struct{}
+	closed   bool
+	commands []string
+}
+
+// NewServer starts a server, requiring AUTH with password when it is not
+// empty.
+func NewServer(password string) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		password: password,
+		data:     make(map[string]entry),
+		now:      time.Now,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	go s.serve()
+
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Advance moves the server clock forward, expiring keys as a real server
+// would after d has elapsed.
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.now = func() time.Time { return now.Add(d) }
+}
+
+// Keys returns the keys currently stored and not expired.
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key, entry := range s.data {
+		if !s.expired(entry) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Commands returns every command received after authentication, in order.
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	authenticated := s.password == ""
+	for {
+		request, err := cache.ReadReply(reader)
+		if err != nil {
+			return
+		}
+
+		values, ok := request.([]interface{})
+		if !ok || len(values) == 0 {
+			conn.Write([]byte("-ERR protocol error\r\n"))
+			return
+		}
+
+		args := make([]string, len(values))
+		for i, value := range values {
+			args[i], _ = value.(string)
+		}
+
+		name := strings.ToUpper(args[0])
+		if name == "AUTH" {
+			if len(args) == 2 && args[1] == s.password {
+				authenticated = true
+				conn.Write([]byte("+OK\r\n"))
+			} else {
+				conn.Write([]byte("-WRONGPASS invalid password\r\n"))
+			}
+			continue
+		}
+		if !authenticated {
+			conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+			continue
+		}
+
+		conn.Write(s.execute(name, args[1:]))
+	}
+}
+
+func (s *Server) execute(name string, args []string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands = append(s.commands, strings.Join(append([]string{name}, args...), " "))
+
+	switch name {
+	case "PING":
+		return []byte("+PONG\r\n")
+	case "SELECT":
+		return []byte("+OK\r\n")
+	case "FLUSHALL":
+		s.data = make(map[string]entry)
+		return []byte("+OK\r\n")
+	case "GET":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		entry, ok := s.data[args[0]]
+		if !ok || s.expired(entry) {
+			delete(s.data, args[0])
+			return []byte("$-1\r\n")
+		}
+		return bulk(entry.value)
+	case "SET":
+		return s.set(args)
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				deleted++
+			}
+		}
+		return []byte(fmt.Sprintf(":%d\r\n", deleted))
+	case "TTL":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		entry, ok := s.data[args[0]]
+		switch {
+		case !ok || s.expired(entry):
+			return []byte(":-2\r\n")
+		case entry.expiresAt.IsZero():
+			return []byte(":-1\r\n")
+		}
+		return []byte(fmt.Sprintf(":%d\r\n", int64(entry.expiresAt.Sub(s.now()).Seconds())))
+	}
+
+	return []byte(fmt.Sprintf("-ERR unknown command '%s'\r\n", name))
+}
+
+func (s *Server) set(args []string) []byte {
+	if len(args) != 2 && len(args) != 4 {
+		return wrongArgs("SET")
+	}
+
+	e := entry{value: args[1]}
+	if len(args) == 4 {
+		amount, err := strconv.ParseInt(args[3], 10, 64)
+		if err != nil || amount <= 0 {
+			return []byte("-ERR invalid expire time in 'set' command\r\n")
+		}
+
+		switch strings.ToUpper(args[2]) {
+		case "EX":
+			e.expiresAt = s.now().Add(time.Duration(amount) * time.Second)
+		case "PX":
+			e.expiresAt = s.now().Add(time.Duration(amount) * time.Millisecond)
+		default:
+			return []byte("-ERR syntax error\r\n")
+		}
+	}
+
+	s.data[args[0]] = e
+	return []byte("+OK\r\n")
+}
+
+func (s *Server) expired(e entry) bool {
+	return !e.expiresAt.IsZero() && !s.now().Before(e.expiresAt)
+}
+
+func bulk(value string) []byte {
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(value), value))
+}
+
+func wrongArgs(name string) []byte {
+	return []byte(fmt.Sprintf("-ERR wrong number of arguments for '%s' command\r\n", strings.ToLower(name)))
+}
@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRedisPoolSize    = 10
+	defaultRedisDialTimeout = time.Second
+	defaultRedisTimeout     = time.Second
+)
+
+// RedisError is an error reply sent by the server.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return string(e)
+}
+
+type RedisOptions struct {
+	Addr        string
+	Password    string
+	DB          int
+	PoolSize    int
+	DialTimeout time.Duration
+	// Timeout bounds every command, so that a stalled server cannot hang
+	// lookups whose context has no deadline or a later one.
+	Timeout time.Duration
+}
+
+// RedisClient is a minimal client for servers speaking RESP, the Redis
+// serialization protocol. Connections are pooled and authenticated on dial.
+type RedisClient struct {
+	options RedisOptions
+	pool    chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+func NewRedisClient(options RedisOptions) *RedisClient {
+	if options.PoolSize <= 0 {
+		options.PoolSize = defaultRedisPoolSize
+	}
+	if options.DialTimeout <= 0 {
+		options.DialTimeout = defaultRedisDialTimeout
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultRedisTimeout
+	}
+
+	return &RedisClient{
+		options: options,
+		pool:    make(chan *redisConn, options.PoolSize),
+	}
+}
+
+// Do sends a command and returns its reply: a string for simple and bulk
+// strings, an int64 for integers, a []interface{} for arrays and nil for nil
+// replies. Error replies are returned as RedisError.
+func (c *RedisClient) Do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(ctx, args...)
+	var redisErr RedisError
+	if err != nil && !errors.As(err, &redisErr) {
+		conn.Close()
+		return nil, err
+	}
+
+	c.release(conn)
+	return reply, err
+}
+
+func (c *RedisClient) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+func (c *RedisClient) Close() error {
+	for {
+		select {
+		case conn := <-c.pool:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *RedisClient) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.pool:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.options.DialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.options.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn), timeout: c.options.Timeout}
+
+	if c.options.Password != "" {
+		if _, err := conn.do(ctx, "AUTH", c.options.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.options.DB != 0 {
+		if _, err := conn.do(ctx, "SELECT", strconv.Itoa(c.options.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+func (c *RedisClient) release(conn *redisConn) {
+	select {
+	case c.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (conn *redisConn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline := time.Now().Add(conn.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(EncodeCommand(args...)); err != nil {
+		return nil, err
+	}
+
+	return ReadReply(conn.reader)
+}
+
+// EncodeCommand serializes a command as a RESP array of bulk strings.
+func EncodeCommand(args ...string) []byte {
+	buf := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, arg := range args {
+		buf = fmt.Appendf(buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return buf
+}
+
+// ReadReply reads a single RESP value from reader.
+func ReadReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("resp: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, RedisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("resp: invalid bulk length %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("resp: invalid array length %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, size)
+		for i := range values {
+			if values[i], err = ReadReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("resp: unexpected reply %q", line)
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("resp: malformed line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
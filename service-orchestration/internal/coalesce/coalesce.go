@@ -0,0 +1,114 @@
+package coalesce
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type call[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	span    trace.Span
+	waiters int
+	// deadline is the deadline of the call, zero when it has none.
+	deadline time.Time
+}
+
+// outlasts tells whether c runs at least as long as ctx may wait for it.
+func (c *call[V]) outlasts(ctx context.Context) bool {
+	if c.deadline.IsZero() {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && !deadline.After(c.deadline)
+}
+
+// Group deduplicates concurrent calls sharing a key: the first caller runs
+// the function and everybody arriving while it is in flight waits for, and
+// receives, the same result.
+//
+// The shared call gets its own span under the first caller's trace, linked to
+// the span of every request that joined it.
+type Group[V any] struct {
+	name  string
+	mu    sync.Mutex
+	calls map[string]*call[V]
+}
+
+func NewGroup[V any](name string) *Group[V] {
+	return &Group[V]{
+		name:  name,
+		calls: make(map[string]*call[V]),
+	}
+}
+
+// Do runs fn once for all concurrent callers of key. The shared call is
+// detached from the cancellation of the caller that started it, so one
+// client going away does not fail the others; it keeps that caller's
+// deadline though. A caller whose deadline is later than the one of the call
+// in flight starts a new call rather than joining, which the callers arriving
+// after it join, so that nobody fails on a budget shorter than its own.
+// shared reports whether the result came from a call started by another
+// request.
+func (g *Group[V]) Do(ctx context.Context, key string, fn func(ctx context.Context) (V, error)) (value V, err error, shared bool) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok && c.outlasts(ctx) {
+		c.waiters++
+		c.span.AddLink(trace.LinkFromContext(ctx, attribute.String("coalesce.role", "waiter")))
+		g.mu.Unlock()
+
+		select {
+		case <-c.done:
+			return c.value, c.err, true
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err(), true
+		}
+	}
+
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	callCtx, span := tracer.Start(context.WithoutCancel(ctx), "Coalesce."+g.name,
+		trace.WithLinks(trace.LinkFromContext(ctx, attribute.String("coalesce.role", "leader"))),
+		trace.WithAttributes(attribute.String("coalesce.key", key)),
+	)
+	c := &call[V]{done: make(chan struct{}), span: span}
+	cancel := func() {}
+	if deadline, ok := ctx.Deadline(); ok {
+		callCtx, cancel = context.WithDeadline(callCtx, deadline)
+		c.deadline = deadline
+	}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		c.value, c.err = fn(callCtx)
+		telemetry.RecordError(span, c.err)
+
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		span.SetAttributes(attribute.Int("coalesce.waiters", c.waiters))
+		g.mu.Unlock()
+
+		span.End()
+		close(c.done)
+	}()
+
+	select {
+	case <-c.done:
+		return c.value, c.err, false
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err(), false
+	}
+}
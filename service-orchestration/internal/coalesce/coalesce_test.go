@@ -0,0 +1,188 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDoSharesConcurrentCalls(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	tracer := otel.Tracer("test")
+
+	group := NewGroup[int]("test")
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	const callers = 5
+	var wg sync.WaitGroup
+	var shared atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, span := tracer.Start(context.Background(), "request")
+			defer span.End()
+
+			value, err, isShared := group.Do(ctx, "key", func(ctx context.Context) (int, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, 42, value)
+			if isShared {
+				shared.Add(1)
+			}
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		c, ok := group.calls["key"]
+		return ok && c.waiters == callers-1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int32(callers-1), shared.Load())
+
+	var coalesced sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "Coalesce.test" {
+			coalesced = span
+		}
+	}
+	if assert.NotNil(t, coalesced) {
+		assert.Len(t, coalesced.Links(), callers)
+	}
+}
+
+func TestDoDoesNotFailWaitersWhenLeaderGoesAway(t *testing.T) {
+	group := NewGroup[int]("test")
+	release := make(chan struct{})
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err, _ := group.Do(leaderCtx, "key", func(ctx context.Context) (int, error) {
+			<-release
+			return 1, ctx.Err()
+		})
+		leaderErr <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		_, ok := group.calls["key"]
+		return ok
+	}, time.Second, time.Millisecond)
+
+	waiterResult := make(chan error)
+	go func() {
+		_, err, _ := group.Do(context.Background(), "key", nil)
+		waiterResult <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		return group.calls["key"].waiters == 1
+	}, time.Second, time.Millisecond)
+
+	cancelLeader()
+	assert.True(t, errors.Is(<-leaderErr, context.Canceled))
+
+	close(release)
+	assert.NoError(t, <-waiterResult)
+}
+
+func TestDoDoesNotHoldLongerBudgetsToShorterDeadlines(t *testing.T) {
+	group := NewGroup[int]("test")
+	var calls atomic.Int32
+	fn := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	shortCtx, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	shortErr := make(chan error)
+	go func() {
+		_, err, _ := group.Do(shortCtx, "key", fn)
+		shortErr <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		_, ok := group.calls["key"]
+		return ok
+	}, time.Second, time.Millisecond)
+
+	longCtx, cancelLong := context.WithTimeout(context.Background(), time.Second)
+	defer cancelLong()
+	value, err, shared := group.Do(longCtx, "key", fn)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+	assert.False(t, shared, "the longer budget starts its own call")
+	assert.ErrorIs(t, <-shortErr, context.DeadlineExceeded)
+	assert.Equal(t, int32(2), calls.Load())
+
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	assert.Empty(t, group.calls, "the first call does not remove the second")
+}
+
+func TestDoJoinsCallsOutlastingTheCaller(t *testing.T) {
+	group := NewGroup[int]("test")
+	release := make(chan struct{})
+
+	longCtx, cancelLong := context.WithTimeout(context.Background(), time.Second)
+	defer cancelLong()
+	go group.Do(longCtx, "key", func(ctx context.Context) (int, error) {
+		<-release
+		return 42, nil
+	})
+
+	assert.Eventually(t, func() bool {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		_, ok := group.calls["key"]
+		return ok
+	}, time.Second, time.Millisecond)
+
+	shortCtx, cancelShort := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancelShort()
+	result := make(chan bool)
+	go func() {
+		_, _, shared := group.Do(shortCtx, "key", nil)
+		result <- shared
+	}()
+
+	assert.Eventually(t, func() bool {
+		group.mu.Lock()
+		defer group.mu.Unlock()
+		return group.calls["key"].waiters == 1
+	}, time.Second, time.Millisecond)
+	close(release)
+	assert.True(t, <-result)
+}
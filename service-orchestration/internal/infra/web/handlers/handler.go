@@ -1,73 +1,78 @@
 package handlers
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strings"
 
+	"github.com/kameikay/service-orchestration/internal/cache"
 	"github.com/kameikay/service-orchestration/internal/service"
 	"github.com/kameikay/service-orchestration/internal/usecase"
-	"github.com/kameikay/service-orchestration/pkg/exceptions"
-	"github.com/kameikay/service-orchestration/pkg/utils"
+	"github.com/kameikay/shared/pkg/batch"
+	"github.com/kameikay/shared/pkg/deadline"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/kameikay/shared/pkg/utils"
 	"github.com/spf13/viper"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Handler struct {
-	viaCepService     service.ViaCepServiceInterface
-	weatherApiService service.WeatherApiServiceInterface
+	logger             *slog.Logger
+	viaCepService      service.ViaCepServiceInterface
+	weatherApiServices []service.WeatherApiServiceInterface
 }
 
 func NewHandler(
+	logger *slog.Logger,
 	viaCepService service.ViaCepServiceInterface,
-	weatherApiService service.WeatherApiServiceInterface,
+	weatherApiServices ...service.WeatherApiServiceInterface,
 ) *Handler {
 	return &Handler{
-		viaCepService:     viaCepService,
-		weatherApiService: weatherApiService,
+		logger:             logger,
+		viaCepService:      viaCepService,
+		weatherApiServices: weatherApiServices,
 	}
 }
 
 func (h *Handler) GetTemperatures(w http.ResponseWriter, r *http.Request) {
-	carrier := propagation.HeaderCarrier(r.Header)
-	ctx := otel.GetTextMapPropagator().Extract(r.Context(), carrier)
 	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
-
-	ctx, span := tracer.Start(ctx, "GetTemperaturesHandler")
+	ctx, span := tracer.Start(r.Context(), "GetTemperaturesHandler")
 	defer span.End()
+	deadline.Annotate(ctx, span)
+
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		ctx = cache.WithBypass(ctx)
+	}
 
 	cepParam := r.URL.Query().Get("cep")
+	span.SetAttributes(telemetry.CEP(cepParam))
 	cep, err := h.formatCEP(cepParam)
 	if err != nil {
-		utils.JsonResponse(w, utils.ResponseDTO{
-			StatusCode: http.StatusUnprocessableEntity,
-			Message:    err.Error(),
-			Success:    false,
-		})
+		telemetry.ValidationFailed(span, "cep", err)
+		utils.ErrorResponse(w, r, err)
 		return
 	}
 
-	getTemperaturesUseCase := usecase.NewGetTemperatureUseCase(h.viaCepService, h.weatherApiService)
+	ctx = logger.WithCEP(ctx, cep)
+
+	getTemperaturesUseCase := usecase.NewGetTemperatureUseCase(h.logger, h.viaCepService, h.weatherApiServices...)
 	data, err := getTemperaturesUseCase.Execute(ctx, cep)
 	if err != nil {
-		if err == exceptions.ErrCannotFindZipcode {
-			utils.JsonResponse(w, utils.ResponseDTO{
-				StatusCode: http.StatusNotFound,
-				Message:    err.Error(),
-				Success:    false,
-			})
-			return
-		}
-
-		utils.JsonResponse(w, utils.ResponseDTO{
-			StatusCode: http.StatusBadRequest,
-			Message:    err.Error(),
-			Success:    false,
-		})
+		telemetry.RecordError(span, err)
+		h.logger.WarnContext(ctx, "failed to get temperatures", "error", err)
+
+		utils.ErrorResponse(w, r, err)
 		return
 	}
 
+	span.SetAttributes(telemetry.City(data.City), telemetry.WeatherStale(data.Stale))
+	span.SetAttributes(telemetry.Temperature(data.TempC, data.TempF, data.TempK)...)
+
 	utils.JsonResponse(w, utils.ResponseDTO{
 		StatusCode: http.StatusOK,
 		Message:    http.StatusText(http.StatusOK),
@@ -76,6 +81,57 @@ func (h *Handler) GetTemperatures(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetTemperaturesBatch looks up the temperatures of every CEP of the request,
+// BATCH_CONCURRENCY at a time. Spellings of the same CEP, with or without the
+// dash, are looked up once.
+func (h *Handler) GetTemperaturesBatch(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(r.Context(), "GetTemperaturesBatchHandler")
+	defer span.End()
+	deadline.Annotate(ctx, span)
+
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		ctx = cache.WithBypass(ctx)
+	}
+
+	getTemperaturesUseCase := usecase.NewGetTemperatureUseCase(h.logger, h.viaCepService, h.weatherApiServices...)
+	batch.Serve(w, r, span, h.cepKey, func(ceps []string) []batch.Result[usecase.Response] {
+		return batch.Run(ctx, ceps, h.cepKey, viper.GetInt("BATCH_CONCURRENCY"), func(ctx context.Context, cepParam string) (usecase.Response, error) {
+			ctx, span := tracer.Start(ctx, "GetTemperaturesBatchHandler.Item", trace.WithAttributes(telemetry.CEP(cepParam)))
+			defer span.End()
+
+			cep, err := h.formatCEP(cepParam)
+			if err != nil {
+				telemetry.ValidationFailed(span, "cep", err)
+				return usecase.Response{}, err
+			}
+
+			ctx = logger.WithCEP(ctx, cep)
+
+			data, err := getTemperaturesUseCase.Execute(ctx, cep)
+			if err != nil {
+				telemetry.RecordError(span, err)
+				h.logger.WarnContext(ctx, "failed to get temperatures", "error", err)
+				return usecase.Response{}, err
+			}
+
+			span.SetAttributes(telemetry.City(data.City), telemetry.WeatherStale(data.Stale))
+			span.SetAttributes(telemetry.Temperature(data.TempC, data.TempF, data.TempK)...)
+			return data, nil
+		})
+	})
+}
+
+// cepKey formats cep, so that its spellings are told to be the same. Invalid
+// CEPs are their own key.
+func (h *Handler) cepKey(cep string) string {
+	formatted, err := h.formatCEP(cep)
+	if err != nil {
+		return cep
+	}
+	return formatted
+}
+
 func (h *Handler) formatCEP(cep string) (string, error) {
 	cepRegEx := `^\d{5}-\d{3}$`
 
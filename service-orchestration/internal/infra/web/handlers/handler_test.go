@@ -2,18 +2,29 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/kameikay/service-orchestration/internal/service"
 	mock "github.com/kameikay/service-orchestration/internal/service/mocks"
 	"github.com/kameikay/service-orchestration/internal/usecase"
-	"github.com/kameikay/service-orchestration/pkg/exceptions"
-	"github.com/kameikay/service-orchestration/pkg/utils"
+	"github.com/kameikay/shared/pkg/batch"
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/kameikay/shared/pkg/utils"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type HandlerSuite struct {
@@ -40,7 +51,7 @@ func (suite *HandlerSuite) SetupTest() {
 }
 
 func (suite *HandlerSuite) TestNewHandler() {
-	handler := NewHandler(suite.viaCepService, suite.weatherApiService)
+	handler := NewHandler(logger.Discard(), suite.viaCepService, suite.weatherApiService)
 	suite.NotNil(handler)
 }
 
@@ -50,6 +61,8 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 		cep              string
 		expectations     func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface)
 		expectedResponse utils.ResponseDTO
+		// code is the error code described in the response, if any.
+		code exceptions.Code
 	}{
 		{
 			name: "should return correct temperatures",
@@ -58,12 +71,8 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345-678").Return(&service.ViaCEPResponse{
 					Localidade: "São Paulo",
 				}, nil)
-				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherAPIResponse{
-					Current: struct {
-						TempC float64 `json:"temp_c"`
-					}{
-						TempC: 20,
-					},
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+					TempC: 20,
 				}, nil)
 			},
 			expectedResponse: utils.ResponseDTO{
@@ -75,6 +84,7 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 		},
 		{
 			name: "should return error when cep is invalid",
+			code: exceptions.CodeInvalidCEP,
 			cep:  "123451s",
 			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
 				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345-678").Times(0)
@@ -86,19 +96,21 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 			},
 		},
 		{
-			name: "should return error when there is an error getting data from services",
+			name: "should return bad gateway when an upstream call fails",
+			code: exceptions.CodeUpstreamError,
 			cep:  "12345-678",
 			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
 				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345-678").Return(nil, errors.New("error"))
 			},
 			expectedResponse: utils.ResponseDTO{
-				StatusCode: http.StatusBadRequest,
-				Message:    "error",
+				StatusCode: http.StatusBadGateway,
+				Message:    exceptions.ErrUpstream.Error(),
 				Success:    false,
 			},
 		},
 		{
 			name: "should return error when cep is not found",
+			code: exceptions.CodeZipcodeNotFound,
 			cep:  "12345678",
 			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
 				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345-678").Return(nil, exceptions.ErrCannotFindZipcode)
@@ -109,6 +121,48 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 				Success:    false,
 			},
 		},
+		{
+			name: "should return error when no cep provider is available",
+			code: exceptions.CodeCEPServiceUnavailable,
+			cep:  "12345678",
+			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
+				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345-678").Return(nil, exceptions.ErrCEPServiceUnavailable)
+			},
+			expectedResponse: utils.ResponseDTO{
+				StatusCode: http.StatusServiceUnavailable,
+				Message:    exceptions.ErrCEPServiceUnavailable.Error(),
+				Success:    false,
+			},
+		},
+		{
+			name: "should return error when a circuit breaker is open",
+			code: exceptions.CodeCircuitOpen,
+			cep:  "12345678",
+			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
+				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345-678").Return(&service.ViaCEPResponse{
+					Localidade: "São Paulo",
+				}, nil)
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(nil, &breaker.OpenError{Name: "weatherapi"})
+			},
+			expectedResponse: utils.ResponseDTO{
+				StatusCode: http.StatusServiceUnavailable,
+				Message:    exceptions.ErrCircuitOpen.Error(),
+				Success:    false,
+			},
+		},
+		{
+			name: "should return error when the request budget is exhausted",
+			code: exceptions.CodeDeadlineExceeded,
+			cep:  "12345678",
+			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
+				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345-678").Return(nil, context.DeadlineExceeded)
+			},
+			expectedResponse: utils.ResponseDTO{
+				StatusCode: http.StatusGatewayTimeout,
+				Message:    exceptions.ErrDeadlineExceeded.Error(),
+				Success:    false,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -117,7 +171,7 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 			request := httptest.NewRequest(http.MethodGet, "http://test/?cep="+tc.cep, nil)
 			recorder := httptest.NewRecorder()
 
-			handler := NewHandler(suite.viaCepService, suite.weatherApiService)
+			handler := NewHandler(logger.Discard(), suite.viaCepService, suite.weatherApiService)
 			handler.GetTemperatures(recorder, request)
 
 			suite.Equal(tc.expectedResponse, utils.ResponseDTO{
@@ -126,7 +180,159 @@ func (suite *HandlerSuite) TestGetTemperatures() {
 				Success:    tc.expectedResponse.Success,
 				Data:       tc.expectedResponse.Data,
 			})
+
+			var body utils.Response
+			suite.NoError(json.Unmarshal(recorder.Body.Bytes(), &body))
+			if tc.code == "" {
+				suite.Nil(body.Error)
+			} else {
+				suite.Require().NotNil(body.Error)
+				suite.Equal(tc.code, body.Error.Code)
+				suite.Equal(recorder.Code, body.Error.Status)
+				suite.Equal(tc.expectedResponse.Message, body.Message)
+			}
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestGetTemperaturesSpans() {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	handler := NewHandler(logger.Discard(), suite.viaCepService, suite.weatherApiService)
+	serve := func(cep string) (handlerSpan, useCaseSpan sdktrace.ReadOnlySpan) {
+		recorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+		handler.GetTemperatures(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://test/?cep="+cep, nil))
+		for _, span := range recorder.Ended() {
+			switch span.Name() {
+			case "GetTemperaturesHandler":
+				handlerSpan = span
+			case "GetTemperaturesUseCase.Execute":
+				useCaseSpan = span
+			}
+		}
+		return handlerSpan, useCaseSpan
+	}
+
+	suite.Run("describes the resolved address and temperature", func() {
+		suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "01001-000").Return(&service.ViaCEPResponse{
+			Localidade: "São Paulo",
+			Uf:         "SP",
+			Ibge:       "3550308",
+			Provider:   "viacep",
+		}, nil)
+		suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+			Provider: "weatherapi",
+			TempC:    20,
+		}, nil)
+
+		handlerSpan, useCaseSpan := serve("01001000")
+		suite.Require().NotNil(handlerSpan)
+		suite.Require().NotNil(useCaseSpan)
+
+		suite.Equal(codes.Unset, handlerSpan.Status().Code)
+		suite.Subset(handlerSpan.Attributes(), []attribute.KeyValue{
+			telemetry.CEP("01001000"),
+			telemetry.City("São Paulo"),
+			telemetry.TemperatureCelsius(20),
+			telemetry.TemperatureFahrenheitKey.Float64(68),
+			telemetry.TemperatureKelvinKey.Float64(293),
 		})
+		suite.Subset(useCaseSpan.Attributes(), append(
+			telemetry.Address("São Paulo", "SP", "3550308"),
+			telemetry.CEP("01001-000"),
+			telemetry.CEPProvider("viacep"),
+			telemetry.WeatherProvider("weatherapi"),
+			telemetry.WeatherStale(false),
+		))
+	})
+
+	suite.Run("records validation failures without failing the span", func() {
+		handlerSpan, useCaseSpan := serve("123451s")
+		suite.Require().NotNil(handlerSpan)
+		suite.Nil(useCaseSpan)
+
+		suite.Equal(codes.Unset, handlerSpan.Status().Code)
+		suite.Require().Len(handlerSpan.Events(), 1)
+		suite.Equal(telemetry.ValidationFailedEvent, handlerSpan.Events()[0].Name)
+	})
+
+	suite.Run("marks the spans as failed on errors", func() {
+		suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "01001-000").Return(nil, exceptions.ErrCannotFindZipcode)
+
+		handlerSpan, useCaseSpan := serve("01001000")
+		suite.Require().NotNil(handlerSpan)
+		suite.Require().NotNil(useCaseSpan)
+
+		for _, span := range []sdktrace.ReadOnlySpan{handlerSpan, useCaseSpan} {
+			suite.Equal(sdktrace.Status{Code: codes.Error, Description: exceptions.ErrCannotFindZipcode.Error()}, span.Status())
+			suite.Require().Len(span.Events(), 1)
+			suite.Equal("exception", span.Events()[0].Name)
+		}
+	})
+}
+
+func (suite *HandlerSuite) TestGetTemperaturesBatch() {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+
+	suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "01001-000").Return(&service.ViaCEPResponse{Localidade: "São Paulo"}, nil).Times(1)
+	suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "99999-999").Return(nil, exceptions.ErrCannotFindZipcode).Times(1)
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{TempC: 20}, nil).Times(1)
+
+	request := httptest.NewRequest(http.MethodPost, "http://test/batch", strings.NewReader(`{"ceps":["01001000","123451s","01001-000","99999999"]}`))
+	recorder := httptest.NewRecorder()
+
+	handler := NewHandler(logger.Discard(), suite.viaCepService, suite.weatherApiService)
+	handler.GetTemperaturesBatch(recorder, request)
+
+	suite.Equal(http.StatusOK, recorder.Code)
+
+	var body struct {
+		Data []batch.Item[usecase.Response] `json:"data"`
+	}
+	suite.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &body))
+	suite.Require().Len(body.Data, 4)
+	expected := &usecase.Response{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293}
+	suite.Equal(batch.Item[usecase.Response]{Cep: "01001000", Data: expected}, body.Data[0])
+	suite.Equal(batch.Item[usecase.Response]{Cep: "01001-000", Data: expected}, body.Data[2])
+	suite.Equal("123451s", body.Data[1].Cep)
+	suite.Require().NotNil(body.Data[1].Error)
+	suite.Equal(exceptions.CodeInvalidCEP, body.Data[1].Error.Code)
+	suite.Equal("99999999", body.Data[3].Cep)
+	suite.Require().NotNil(body.Data[3].Error)
+	suite.Equal(exceptions.CodeZipcodeNotFound, body.Data[3].Error.Code)
+
+	var batchSpan sdktrace.ReadOnlySpan
+	var itemSpans []sdktrace.ReadOnlySpan
+	for _, span := range spans.Ended() {
+		switch span.Name() {
+		case "GetTemperaturesBatchHandler":
+			batchSpan = span
+		case "GetTemperaturesBatchHandler.Item":
+			itemSpans = append(itemSpans, span)
+		}
+	}
+	suite.Require().NotNil(batchSpan)
+	suite.Subset(batchSpan.Attributes(), append(telemetry.Batch(4, 3), telemetry.BatchFailedKey.Int(2)))
+	suite.Require().Len(itemSpans, 3, "repeated ceps, however spelled, are looked up once")
+	for _, span := range itemSpans {
+		suite.Equal(batchSpan.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+}
+
+func (suite *HandlerSuite) TestGetTemperaturesBatchRejectsMalformedRequests() {
+	handler := NewHandler(logger.Discard(), suite.viaCepService, suite.weatherApiService)
+
+	for _, body := range []string{`{"ceps":"01001000"}`, `{"ceps":[]}`, `{"ceps":["` + strings.Repeat(`01001000","`, 100) + `01001000"]}`} {
+		recorder := httptest.NewRecorder()
+		handler.GetTemperaturesBatch(recorder, httptest.NewRequest(http.MethodPost, "http://test/batch", strings.NewReader(body)))
+
+		suite.Equal(http.StatusBadRequest, recorder.Code)
 	}
 }
 
@@ -165,7 +371,7 @@ func (suite *HandlerSuite) TestFormatCep() {
 
 	for _, tc := range ceps {
 		suite.T().Run(tc.cep, func(t *testing.T) {
-			handler := NewHandler(suite.viaCepService, suite.weatherApiService)
+			handler := NewHandler(logger.Discard(), suite.viaCepService, suite.weatherApiService)
 			cep, err := handler.formatCEP(tc.cep)
 			suite.Equal(tc.expectedCep, cep)
 			suite.Equal(tc.expectedError, err)
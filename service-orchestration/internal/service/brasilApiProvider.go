@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+)
+
+type brasilAPIPayload struct {
+	Cep          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+}
+
+type BrasilAPIProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewBrasilAPIProvider(client *http.Client) *BrasilAPIProvider {
+	return &BrasilAPIProvider{
+		client:  client,
+		baseURL: "https://brasilapi.com.br/api/cep/v1/",
+	}
+}
+
+func (p *BrasilAPIProvider) Name() string {
+	return "brasilapi"
+}
+
+func (p *BrasilAPIProvider) HealthURL() string {
+	return p.baseURL + probeCEP
+}
+
+func (p *BrasilAPIProvider) GetAddress(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	var payload brasilAPIPayload
+	err := getJSON(ctx, p.client, p.Name(), p.baseURL+onlyDigits(cep), &payload)
+	if err != nil {
+		switch statusCodeOf(err) {
+		case http.StatusNotFound:
+			return nil, exceptions.ErrCannotFindZipcode
+		case http.StatusBadRequest:
+			return nil, exceptions.ErrInvalidCEP
+		}
+		return nil, err
+	}
+
+	if payload.City == "" {
+		return nil, fmt.Errorf("%w: %s: missing city", errMalformedPayload, p.Name())
+	}
+
+	return &ViaCEPResponse{
+		Cep:        cep,
+		Logradouro: payload.Street,
+		Bairro:     payload.Neighborhood,
+		Localidade: payload.City,
+		Uf:         payload.State,
+		Provider:   p.Name(),
+	}, nil
+}
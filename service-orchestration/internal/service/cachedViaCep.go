@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kameikay/service-orchestration/internal/cache"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CachedViaCepService serves CEP lookups from a cache, only reaching the
+// wrapped service on misses or when the request bypasses the cache.
+type CachedViaCepService struct {
+	next    ViaCepServiceInterface
+	store   cache.Store[ViaCEPResponse]
+	metrics *cache.Metrics
+}
+
+func NewCachedViaCepService(next ViaCepServiceInterface, store cache.Store[ViaCEPResponse], metrics *cache.Metrics) *CachedViaCepService {
+	return &CachedViaCepService{
+		next:    next,
+		store:   store,
+		metrics: metrics,
+	}
+}
+
+func (s *CachedViaCepService) GetCEPData(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(ctx, "CachedViaCepService.GetCEPData", trace.WithAttributes(telemetry.CEP(cep)))
+	defer span.End()
+
+	key := onlyDigits(cep)
+	result := cache.ResultBypass
+	if !cache.IsBypassed(ctx) {
+		if address, ok := s.store.Get(ctx, key); ok {
+			span.SetAttributes(telemetry.CacheResult(cache.ResultHit))
+			s.metrics.Record(ctx, cache.ResultHit)
+			return &address, nil
+		}
+		result = cache.ResultMiss
+	}
+	span.SetAttributes(telemetry.CacheResult(result))
+	s.metrics.Record(ctx, result)
+
+	address, err := s.next.GetCEPData(ctx, cep)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+
+	s.store.Set(ctx, key, *address)
+	return address, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kameikay/service-orchestration/internal/cache"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const weatherRefreshTimeout = 10 * time.Second
+
+// WeatherCacheEntry is what CachedWeatherApiService keeps in its store. The
+// fetch time lets entries outlive their TTL and still be served as stale.
+type WeatherCacheEntry struct {
+	Observation WeatherObservation `json:"observation"`
+	FetchedAt   time.Time          `json:"fetched_at"`
+}
+
+// CachedWeatherApiService serves weather observations from a cache, only
+// reaching the wrapped provider on misses or when the request bypasses the
+// cache.
+//
+// Entries older than ttl but younger than ttl+maxStale are served flagged as
+// stale while a background refresh runs, and are also used as a fallback
+// when the provider fails. The store must therefore retain entries for
+// ttl+maxStale.
+type CachedWeatherApiService struct {
+	logger     *slog.Logger
+	next       WeatherApiServiceInterface
+	store      cache.Store[WeatherCacheEntry]
+	metrics    *cache.Metrics
+	ttl        time.Duration
+	maxStale   time.Duration
+	now        func() time.Time
+	refreshing sync.Map
+}
+
+func NewCachedWeatherApiService(
+	logger *slog.Logger,
+	next WeatherApiServiceInterface,
+	store cache.Store[WeatherCacheEntry],
+	metrics *cache.Metrics,
+	ttl time.Duration,
+	maxStale time.Duration,
+) *CachedWeatherApiService {
+	return &CachedWeatherApiService{
+		logger:   logger,
+		next:     next,
+		store:    store,
+		metrics:  metrics,
+		ttl:      ttl,
+		maxStale: maxStale,
+		now:      time.Now,
+	}
+}
+
+func (s *CachedWeatherApiService) GetWeatherData(ctx context.Context, location string) (*WeatherObservation, error) {
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(ctx, "CachedWeatherApiService.GetWeatherData", trace.WithAttributes(telemetry.City(location)))
+	defer span.End()
+
+	key := strings.ToLower(location)
+	result := cache.ResultBypass
+	if !cache.IsBypassed(ctx) {
+		if entry, ok := s.store.Get(ctx, key); ok {
+			age := s.now().Sub(entry.FetchedAt)
+			if age < s.ttl {
+				span.SetAttributes(telemetry.CacheResult(cache.ResultHit))
+				s.metrics.Record(ctx, cache.ResultHit)
+				return &entry.Observation, nil
+			}
+
+			if age < s.ttl+s.maxStale {
+				span.SetAttributes(telemetry.CacheResult(cache.ResultStale), telemetry.WeatherStale(true))
+				s.metrics.Record(ctx, cache.ResultStale)
+				s.refresh(ctx, key, location)
+				return staleObservation(entry), nil
+			}
+		}
+		result = cache.ResultMiss
+	}
+	span.SetAttributes(telemetry.CacheResult(result))
+	s.metrics.Record(ctx, result)
+
+	observation, err := s.fetch(ctx, key, location)
+	if err != nil {
+		if entry, ok := s.store.Get(ctx, key); ok && s.now().Sub(entry.FetchedAt) < s.ttl+s.maxStale {
+			// The request is still answered, so the span is not marked as
+			// failed.
+			span.RecordError(err)
+			span.SetAttributes(telemetry.WeatherStale(true))
+			s.logger.WarnContext(ctx, "weather provider failed, serving stale observation", "location", location, "fetched_at", entry.FetchedAt, "error", err)
+			return staleObservation(entry), nil
+		}
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+
+	return observation, nil
+}
+
+func (s *CachedWeatherApiService) fetch(ctx context.Context, key, location string) (*WeatherObservation, error) {
+	observation, err := s.next.GetWeatherData(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	s.store.Set(ctx, key, WeatherCacheEntry{Observation: *observation, FetchedAt: s.now()})
+	return observation, nil
+}
+
+// refresh fetches location in the background, at most once at a time per
+// key. The refresh outlives the request that triggered it.
+func (s *CachedWeatherApiService) refresh(ctx context.Context, key, location string) {
+	if _, running := s.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(context.WithoutCancel(ctx), "CachedWeatherApiService.Refresh",
+		trace.WithAttributes(attribute.String("cache.key", key), telemetry.City(location)),
+	)
+
+	go func() {
+		defer s.refreshing.Delete(key)
+		defer span.End()
+
+		ctx, cancel := context.WithTimeout(ctx, weatherRefreshTimeout)
+		defer cancel()
+
+		if _, err := s.fetch(ctx, key, location); err != nil {
+			telemetry.RecordError(span, err)
+			s.logger.WarnContext(ctx, "background weather refresh failed", "location", location, "error", err)
+		}
+	}()
+}
+
+func staleObservation(entry WeatherCacheEntry) *WeatherObservation {
+	observation := entry.Observation
+	observation.Stale = true
+	if observation.ObservedAt.IsZero() {
+		observation.ObservedAt = entry.FetchedAt
+	}
+	return &observation
+}
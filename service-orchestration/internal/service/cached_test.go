@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kameikay/service-orchestration/internal/cache"
+	"github.com/kameikay/service-orchestration/internal/service"
+	mock "github.com/kameikay/service-orchestration/internal/service/mocks"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/stretchr/testify/suite"
+)
+
+type CachedServicesSuite struct {
+	suite.Suite
+	ctrl              *gomock.Controller
+	viaCepService     *mock.MockViaCepServiceInterface
+	weatherApiService *mock.MockWeatherApiServiceInterface
+	ctx               context.Context
+}
+
+func TestCachedServicesStart(t *testing.T) {
+	suite.Run(t, new(CachedServicesSuite))
+}
+
+func (suite *CachedServicesSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.viaCepService = mock.NewMockViaCepServiceInterface(suite.ctrl)
+	suite.weatherApiService = mock.NewMockWeatherApiServiceInterface(suite.ctrl)
+	suite.ctx = context.Background()
+}
+
+func (suite *CachedServicesSuite) TestCachedViaCepService() {
+	metrics := cache.NewMetrics("cep")
+	cached := service.NewCachedViaCepService(suite.viaCepService, cache.NewLRU[service.ViaCEPResponse](10, time.Minute), metrics)
+
+	suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "01001-000").Return(&service.ViaCEPResponse{Localidade: "São Paulo"}, nil).Times(1)
+	for i := 0; i < 3; i++ {
+		address, err := cached.GetCEPData(suite.ctx, "01001-000")
+		suite.NoError(err)
+		suite.Equal("São Paulo", address.Localidade)
+	}
+
+	suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "01001-000").Return(&service.ViaCEPResponse{Localidade: "Sao Paulo"}, nil).Times(1)
+	address, err := cached.GetCEPData(cache.WithBypass(suite.ctx), "01001-000")
+	suite.NoError(err)
+	suite.Equal("Sao Paulo", address.Localidade)
+
+	address, err = cached.GetCEPData(suite.ctx, "01001000")
+	suite.NoError(err)
+	suite.Equal("Sao Paulo", address.Localidade)
+
+	ratio, ok := metrics.HitRatio()
+	suite.True(ok)
+	suite.Equal(0.75, ratio)
+}
+
+func (suite *CachedServicesSuite) TestCachedViaCepServiceDoesNotCacheErrors() {
+	cached := service.NewCachedViaCepService(suite.viaCepService, cache.NewLRU[service.ViaCEPResponse](10, time.Minute), cache.NewMetrics("cep"))
+
+	suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "01001-000").Return(nil, errors.New("error")).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := cached.GetCEPData(suite.ctx, "01001-000")
+		suite.Error(err)
+	}
+}
+
+func (suite *CachedServicesSuite) TestCachedWeatherApiService() {
+	cached := service.NewCachedWeatherApiService(logger.Discard(), suite.weatherApiService, cache.NewLRU[service.WeatherCacheEntry](10, time.Hour), cache.NewMetrics("weather"), time.Minute, time.Hour)
+
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{TempC: 20}, nil).Times(1)
+	for _, location := range []string{"São Paulo", "são paulo"} {
+		observation, err := cached.GetWeatherData(suite.ctx, location)
+		suite.NoError(err)
+		suite.Equal(20.0, observation.TempC)
+		suite.False(observation.Stale)
+	}
+
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{TempC: 22}, nil).Times(1)
+	observation, err := cached.GetWeatherData(cache.WithBypass(suite.ctx), "São Paulo")
+	suite.NoError(err)
+	suite.Equal(22.0, observation.TempC)
+}
+
+func (suite *CachedServicesSuite) TestCachedWeatherApiServiceServesStaleWhileRevalidating() {
+	cached := service.NewCachedWeatherApiService(logger.Discard(), suite.weatherApiService, cache.NewLRU[service.WeatherCacheEntry](10, time.Hour), cache.NewMetrics("weather"), time.Millisecond, time.Hour)
+	observedAt := time.Now().Add(-time.Minute)
+
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{TempC: 20, ObservedAt: observedAt}, nil)
+	_, err := cached.GetWeatherData(suite.ctx, "São Paulo")
+	suite.NoError(err)
+	time.Sleep(5 * time.Millisecond)
+
+	refreshed := make(chan struct{})
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").DoAndReturn(func(ctx context.Context, location string) (*service.WeatherObservation, error) {
+		defer close(refreshed)
+		return &service.WeatherObservation{TempC: 25, ObservedAt: time.Now()}, nil
+	})
+
+	observation, err := cached.GetWeatherData(suite.ctx, "São Paulo")
+	suite.NoError(err)
+	suite.True(observation.Stale)
+	suite.Equal(20.0, observation.TempC)
+	suite.Equal(observedAt, observation.ObservedAt)
+	<-refreshed
+}
+
+func (suite *CachedServicesSuite) TestCachedWeatherApiServiceServesStaleOnError() {
+	cached := service.NewCachedWeatherApiService(logger.Discard(), suite.weatherApiService, cache.NewLRU[service.WeatherCacheEntry](10, time.Hour), cache.NewMetrics("weather"), time.Minute, time.Hour)
+
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{TempC: 20}, nil)
+	_, err := cached.GetWeatherData(suite.ctx, "São Paulo")
+	suite.NoError(err)
+
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(nil, errors.New("error"))
+	observation, err := cached.GetWeatherData(cache.WithBypass(suite.ctx), "São Paulo")
+	suite.NoError(err)
+	suite.True(observation.Stale)
+	suite.Equal(20.0, observation.TempC)
+	suite.False(observation.ObservedAt.IsZero())
+
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "Campinas").Return(nil, errors.New("error"))
+	_, err = cached.GetWeatherData(suite.ctx, "Campinas")
+	suite.Error(err)
+}
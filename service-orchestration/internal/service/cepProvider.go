@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kameikay/shared/pkg/httpclient"
+)
+
+// DefaultCEPProviders is the lookup order used when CEP_PROVIDERS is not set.
+var DefaultCEPProviders = []string{"viacep", "brasilapi", "opencep"}
+
+var errMalformedPayload = errors.New("malformed provider payload")
+
+// CEPProvider resolves a CEP against a single upstream API and normalizes
+// the payload into a ViaCEPResponse.
+type CEPProvider interface {
+	Name() string
+	GetAddress(ctx context.Context, cep string) (*ViaCEPResponse, error)
+}
+
+type upstreamStatusError struct {
+	provider   string
+	statusCode int
+}
+
+func (e *upstreamStatusError) Error() string {
+	return fmt.Sprintf("%s responded with status %d", e.provider, e.statusCode)
+}
+
+var cepProviderRegistry = map[string]func(client *http.Client) CEPProvider{
+	"viacep":    func(client *http.Client) CEPProvider { return NewViaCepProvider(client) },
+	"brasilapi": func(client *http.Client) CEPProvider { return NewBrasilAPIProvider(client) },
+	"opencep":   func(client *http.Client) CEPProvider { return NewOpenCepProvider(client) },
+}
+
+// NewCEPProviders builds the providers registered under the given names,
+// preserving their order. An empty list falls back to DefaultCEPProviders.
+func NewCEPProviders(names ...string) ([]CEPProvider, error) {
+	if len(names) == 0 {
+		names = DefaultCEPProviders
+	}
+
+	providers := make([]CEPProvider, 0, len(names))
+	for _, name := range names {
+		factory, ok := cepProviderRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cep provider %q", name)
+		}
+		providers = append(providers, factory(httpclient.NewHTTPClient(name)))
+	}
+
+	return providers, nil
+}
+
+// ParseProviderNames splits a comma separated provider list as found in the
+// environment, dropping blanks and normalizing case.
+func ParseProviderNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func onlyDigits(cep string) string {
+	return strings.ReplaceAll(cep, "-", "")
+}
+
+func getJSON(ctx context.Context, client *http.Client, provider, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return httpclient.Redact(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return &upstreamStatusError{provider: provider, statusCode: res.StatusCode}
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return fmt.Errorf("%w: %s: %v", errMalformedPayload, provider, err)
+	}
+
+	return nil
+}
+
+func statusCodeOf(err error) int {
+	var statusErr *upstreamStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode
+	}
+	return 0
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kameikay/service-orchestration/internal/coalesce"
+)
+
+// CoalescedViaCepService lets concurrent lookups of the same CEP share a
+// single call to the wrapped service.
+type CoalescedViaCepService struct {
+	next  ViaCepServiceInterface
+	group *coalesce.Group[ViaCEPResponse]
+}
+
+func NewCoalescedViaCepService(next ViaCepServiceInterface) *CoalescedViaCepService {
+	return &CoalescedViaCepService{
+		next:  next,
+		group: coalesce.NewGroup[ViaCEPResponse]("GetCEPData"),
+	}
+}
+
+func (s *CoalescedViaCepService) GetCEPData(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	address, err, _ := s.group.Do(ctx, onlyDigits(cep), func(ctx context.Context) (ViaCEPResponse, error) {
+		address, err := s.next.GetCEPData(ctx, cep)
+		if err != nil {
+			return ViaCEPResponse{}, err
+		}
+		return *address, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
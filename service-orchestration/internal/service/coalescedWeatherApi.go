@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/kameikay/service-orchestration/internal/coalesce"
+)
+
+// CoalescedWeatherApiService lets concurrent lookups of the same location
+// share a single call to the wrapped provider.
+type CoalescedWeatherApiService struct {
+	next  WeatherApiServiceInterface
+	group *coalesce.Group[WeatherObservation]
+}
+
+func NewCoalescedWeatherApiService(next WeatherApiServiceInterface) *CoalescedWeatherApiService {
+	return &CoalescedWeatherApiService{
+		next:  next,
+		group: coalesce.NewGroup[WeatherObservation]("GetWeatherData"),
+	}
+}
+
+func (s *CoalescedWeatherApiService) GetWeatherData(ctx context.Context, location string) (*WeatherObservation, error) {
+	observation, err, _ := s.group.Do(ctx, strings.ToLower(location), func(ctx context.Context) (WeatherObservation, error) {
+		observation, err := s.next.GetWeatherData(ctx, location)
+		if err != nil {
+			return WeatherObservation{}, err
+		}
+		return *observation, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &observation, nil
+}
@@ -0,0 +1,14 @@
+package service
+
+// HealthProber is implemented by the adapters whose upstream API can be
+// probed by /health/dependencies.
+type HealthProber interface {
+	// HealthURL returns a URL answering 2xx while the API works for us.
+	HealthURL() string
+}
+
+// Well known inputs the probes look up: the CEP of Praça da Sé and its city.
+const (
+	probeCEP      = "01001000"
+	probeLocation = "São Paulo"
+)
@@ -36,10 +36,10 @@ func (m *MockWeatherApiServiceInterface) EXPECT() *MockWeatherApiServiceInterfac
 }
 
 // GetWeatherData mocks base method.
-func (m *MockWeatherApiServiceInterface) GetWeatherData(ctx context.Context, location string) (*service.WeatherAPIResponse, error) {
+func (m *MockWeatherApiServiceInterface) GetWeatherData(ctx context.Context, location string) (*service.WeatherObservation, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetWeatherData", ctx, location)
-	ret0, _ := ret[0].(*service.WeatherAPIResponse)
+	ret0, _ := ret[0].(*service.WeatherObservation)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
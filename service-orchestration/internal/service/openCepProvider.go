@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+)
+
+type OpenCepProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewOpenCepProvider(client *http.Client) *OpenCepProvider {
+	return &OpenCepProvider{
+		client:  client,
+		baseURL: "https://opencep.com/v1/",
+	}
+}
+
+func (p *OpenCepProvider) Name() string {
+	return "opencep"
+}
+
+func (p *OpenCepProvider) HealthURL() string {
+	return p.baseURL + probeCEP
+}
+
+func (p *OpenCepProvider) GetAddress(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	// OpenCEP mirrors the ViaCEP payload, without the "erro" flag.
+	var payload ViaCEPResponse
+	err := getJSON(ctx, p.client, p.Name(), p.baseURL+onlyDigits(cep), &payload)
+	if err != nil {
+		if statusCodeOf(err) == http.StatusNotFound {
+			return nil, exceptions.ErrCannotFindZipcode
+		}
+		return nil, err
+	}
+
+	if payload.Localidade == "" {
+		return nil, fmt.Errorf("%w: %s: missing localidade", errMalformedPayload, p.Name())
+	}
+
+	payload.Provider = p.Name()
+	return &payload, nil
+}
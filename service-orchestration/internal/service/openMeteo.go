@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/httpclient"
+)
+
+type openMeteoGeocodingResponse struct {
+	Results []struct {
+		Name      string  `json:"name"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"results"`
+}
+
+type openMeteoForecastResponse struct {
+	Current struct {
+		Time          int64   `json:"time"`
+		Temperature2m float64 `json:"temperature_2m"`
+	} `json:"current"`
+}
+
+// OpenMeteoService reads the current temperature from Open-Meteo, which needs
+// no API key but only understands coordinates, so the location is geocoded
+// first.
+type OpenMeteoService struct {
+	client       *http.Client
+	geocodingURL string
+	forecastURL  string
+}
+
+func NewOpenMeteoService() *OpenMeteoService {
+	return &OpenMeteoService{
+		client:       httpclient.NewHTTPClient(WeatherProviderOpenMeteo),
+		geocodingURL: "https://geocoding-api.open-meteo.com/v1/search",
+		forecastURL:  "https://api.open-meteo.com/v1/forecast",
+	}
+}
+
+func (s *OpenMeteoService) GetWeatherData(ctx context.Context, location string) (*WeatherObservation, error) {
+	return traceWeatherData(ctx, "OpenMeteo.GetWeatherData", WeatherProviderOpenMeteo, location, s.getWeatherData)
+}
+
+// HealthURL asks for the forecast at the coordinates of São Paulo, which is
+// what every lookup ends with.
+func (s *OpenMeteoService) HealthURL() string {
+	return s.forecastURLAt(-23.5475, -46.63611)
+}
+
+func (s *OpenMeteoService) getWeatherData(ctx context.Context, location string) (*WeatherObservation, error) {
+	var geocoding openMeteoGeocodingResponse
+	geocodingURL := fmt.Sprintf("%s?name=%s&count=1&language=pt&countryCode=BR", s.geocodingURL, url.QueryEscape(location))
+	if err := getJSON(ctx, s.client, WeatherProviderOpenMeteo, geocodingURL, &geocoding); err != nil {
+		return nil, err
+	}
+
+	if len(geocoding.Results) == 0 {
+		return nil, exceptions.ErrCannotFindWeatherData
+	}
+	place := geocoding.Results[0]
+
+	var forecast openMeteoForecastResponse
+	if err := getJSON(ctx, s.client, WeatherProviderOpenMeteo, s.forecastURLAt(place.Latitude, place.Longitude), &forecast); err != nil {
+		return nil, err
+	}
+
+	return &WeatherObservation{
+		Provider:   WeatherProviderOpenMeteo,
+		Location:   place.Name,
+		TempC:      forecast.Current.Temperature2m,
+		ObservedAt: time.Unix(forecast.Current.Time, 0).UTC(),
+	}, nil
+}
+
+func (s *OpenMeteoService) forecastURLAt(latitude, longitude float64) string {
+	return fmt.Sprintf("%s?latitude=%f&longitude=%f&current=temperature_2m&timeformat=unixtime", s.forecastURL, latitude, longitude)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/httpclient"
+	"github.com/spf13/viper"
+)
+
+type openWeatherMapResponse struct {
+	Name string `json:"name"`
+	Dt   int64  `json:"dt"`
+	Main struct {
+		Temp float64 `json:"temp"`
+	} `json:"main"`
+}
+
+type OpenWeatherMapService struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewOpenWeatherMapService() *OpenWeatherMapService {
+	return &OpenWeatherMapService{
+		client:  httpclient.NewHTTPClient(WeatherProviderOpenWeatherMap),
+		baseURL: "https://api.openweathermap.org/data/2.5/weather",
+	}
+}
+
+func (s *OpenWeatherMapService) GetWeatherData(ctx context.Context, location string) (*WeatherObservation, error) {
+	return traceWeatherData(ctx, "OpenWeatherMap.GetWeatherData", WeatherProviderOpenWeatherMap, location, s.getWeatherData)
+}
+
+func (s *OpenWeatherMapService) HealthURL() string {
+	return s.weatherURL(probeLocation)
+}
+
+func (s *OpenWeatherMapService) getWeatherData(ctx context.Context, location string) (*WeatherObservation, error) {
+	var response openWeatherMapResponse
+	if err := getJSON(ctx, s.client, WeatherProviderOpenWeatherMap, s.weatherURL(location), &response); err != nil {
+		if statusCodeOf(err) == http.StatusNotFound {
+			return nil, exceptions.ErrCannotFindWeatherData
+		}
+		return nil, err
+	}
+
+	return &WeatherObservation{
+		Provider:   WeatherProviderOpenWeatherMap,
+		Location:   response.Name,
+		TempC:      response.Main.Temp,
+		ObservedAt: time.Unix(response.Dt, 0).UTC(),
+	}, nil
+}
+
+func (s *OpenWeatherMapService) weatherURL(location string) string {
+	OPENWEATHERMAP_API_KEY := viper.GetString("OPENWEATHERMAP_API_KEY")
+	return fmt.Sprintf("%s?q=%s,BR&units=metric&appid=%s", s.baseURL, url.QueryEscape(location), OPENWEATHERMAP_API_KEY)
+}
@@ -2,14 +2,20 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"net/http"
+	"errors"
+	"log/slog"
+	"time"
 
-	"github.com/kameikay/service-orchestration/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/deadline"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/telemetry"
 	"github.com/spf13/viper"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const defaultCEPProviderTimeout = 3 * time.Second
+
 type ViaCEPResponse struct {
 	Erro        string `json:"erro"`
 	Cep         string `json:"cep"`
@@ -22,50 +28,79 @@ type ViaCEPResponse struct {
 	Gia         string `json:"gia"`
 	Ddd         string `json:"ddd"`
 	Siafi       string `json:"siafi"`
+	Provider    string `json:"provider,omitempty"`
 }
 
 type ViaCepServiceInterface interface {
 	GetCEPData(ctx context.Context, cep string) (*ViaCEPResponse, error)
 }
 
+// ViaCepService resolves CEPs through an ordered chain of providers, moving
+// on to the next one whenever a provider times out, fails or answers with a
+// payload that cannot be used.
 type ViaCepService struct {
-	client *http.Client
+	logger    *slog.Logger
+	providers []CEPProvider
 }
 
-func NewViaCepService() *ViaCepService {
-	return &ViaCepService{client: &http.Client{}}
+func NewViaCepService(logger *slog.Logger, providers ...CEPProvider) *ViaCepService {
+	return &ViaCepService{
+		logger:    logger,
+		providers: providers,
+	}
 }
 
 func (s *ViaCepService) GetCEPData(ctx context.Context, cep string) (*ViaCEPResponse, error) {
 	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
-	ctx, span := tracer.Start(ctx, "ViaCEPService.GetCEPData")
+	ctx, span := tracer.Start(ctx, "ViaCEPService.GetCEPData", trace.WithAttributes(telemetry.CEP(cep)))
 	defer span.End()
 
-	url := "http://viacep.com.br/ws/" + cep + "/json"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	res, err := s.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
+	for _, provider := range s.providers {
+		address, err := s.getAddress(ctx, provider, cep)
+		if err == nil {
+			span.SetAttributes(telemetry.CEPProvider(provider.Name()))
+			span.SetAttributes(telemetry.Address(address.Localidade, address.Uf, address.Ibge)...)
+			return address, nil
+		}
 
-	if res.StatusCode == 200 {
-		var viaCEPResponse ViaCEPResponse
-		err = json.NewDecoder(res.Body).Decode(&viaCEPResponse)
-		if err != nil {
+		// Not found and invalid CEP are authoritative answers, and a cancelled
+		// request has nobody left to answer to.
+		if errors.Is(err, exceptions.ErrCannotFindZipcode) || errors.Is(err, exceptions.ErrInvalidCEP) {
+			telemetry.RecordError(span, err)
 			return nil, err
 		}
-
-		if viaCEPResponse.Erro == "true" {
-			return nil, exceptions.ErrCannotFindZipcode
+		if ctx.Err() != nil {
+			telemetry.RecordError(span, ctx.Err())
+			return nil, ctx.Err()
 		}
 
-		return &viaCEPResponse, nil
+		s.logger.WarnContext(ctx, "cep provider failed, trying the next one", "provider", provider.Name(), "error", err)
+	}
+
+	telemetry.RecordError(span, exceptions.ErrCEPServiceUnavailable)
+	return nil, exceptions.ErrCEPServiceUnavailable
+}
+
+func (s *ViaCepService) getAddress(ctx context.Context, provider CEPProvider, cep string) (*ViaCEPResponse, error) {
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(ctx, "CEPProvider."+provider.Name(),
+		trace.WithAttributes(telemetry.CEP(cep), telemetry.CEPProvider(provider.Name())),
+	)
+	defer span.End()
+
+	timeout := viper.GetDuration("CEP_PROVIDER_TIMEOUT")
+	if timeout <= 0 {
+		timeout = defaultCEPProviderTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	deadline.Annotate(ctx, span)
+
+	address, err := provider.GetAddress(ctx, cep)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
 	}
 
-	return nil, nil
+	return address, nil
 }
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+)
+
+type viaCepPayload struct {
+	ViaCEPResponse
+	Erro json.RawMessage `json:"erro"`
+}
+
+type ViaCepProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewViaCepProvider(client *http.Client) *ViaCepProvider {
+	return &ViaCepProvider{
+		client:  client,
+		baseURL: "http://viacep.com.br/ws/",
+	}
+}
+
+func (p *ViaCepProvider) Name() string {
+	return "viacep"
+}
+
+func (p *ViaCepProvider) HealthURL() string {
+	return p.baseURL + probeCEP + "/json"
+}
+
+func (p *ViaCepProvider) GetAddress(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	var payload viaCepPayload
+	err := getJSON(ctx, p.client, p.Name(), p.baseURL+onlyDigits(cep)+"/json", &payload)
+	if err != nil {
+		if statusCodeOf(err) == http.StatusBadRequest {
+			return nil, exceptions.ErrInvalidCEP
+		}
+		return nil, err
+	}
+
+	// ViaCEP answers 200 for unknown CEPs and flags them with "erro", which has
+	// been served both as a boolean and as a string.
+	if erro := string(payload.Erro); erro == "true" || erro == `"true"` {
+		return nil, exceptions.ErrCannotFindZipcode
+	}
+
+	if payload.Localidade == "" {
+		return nil, fmt.Errorf("%w: %s: missing localidade", errMalformedPayload, p.Name())
+	}
+
+	address := payload.ViaCEPResponse
+	address.Provider = p.Name()
+	return &address, nil
+}
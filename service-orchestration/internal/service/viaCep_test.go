@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type ViaCepServiceSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func TestViaCepServiceStart(t *testing.T) {
+	suite.Run(t, new(ViaCepServiceSuite))
+}
+
+func (suite *ViaCepServiceSuite) SetupTest() {
+	suite.ctx = context.Background()
+	viper.Set("CEP_PROVIDER_TIMEOUT", 200*time.Millisecond)
+}
+
+func (suite *ViaCepServiceSuite) TearDownTest() {
+	viper.Set("CEP_PROVIDER_TIMEOUT", nil)
+}
+
+func newTestServer(t *testing.T, status int, body string, delay time.Duration) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/"
+}
+
+func (suite *ViaCepServiceSuite) TestNewCEPProviders() {
+	providers, err := NewCEPProviders()
+	suite.NoError(err)
+	suite.Len(providers, len(DefaultCEPProviders))
+
+	providers, err = NewCEPProviders("opencep", "viacep")
+	suite.NoError(err)
+	suite.Equal("opencep", providers[0].Name())
+	suite.Equal("viacep", providers[1].Name())
+
+	_, err = NewCEPProviders("unknown")
+	suite.Error(err)
+}
+
+func (suite *ViaCepServiceSuite) TestCEPProvidersHealthURL() {
+	providers, err := NewCEPProviders()
+	suite.Require().NoError(err)
+
+	for _, provider := range providers {
+		prober, ok := provider.(HealthProber)
+		suite.Require().True(ok, provider.Name())
+		suite.Contains(prober.HealthURL(), probeCEP)
+	}
+}
+
+func (suite *ViaCepServiceSuite) TestParseProviderNames() {
+	suite.Equal([]string{"viacep", "brasilapi"}, ParseProviderNames(" ViaCEP, ,brasilapi "))
+	suite.Nil(ParseProviderNames(""))
+}
+
+func (suite *ViaCepServiceSuite) TestGetCEPData() {
+	viaCepOK := `{"cep":"01001-000","localidade":"São Paulo","uf":"SP","ibge":"3550308"}`
+	brasilAPIOK := `{"cep":"01001000","state":"SP","city":"São Paulo","neighborhood":"Sé","street":"Praça da Sé"}`
+
+	testCases := []struct {
+		name             string
+		providers        func(t *testing.T) []CEPProvider
+		expectedCity     string
+		expectedProvider string
+		expectedErr      error
+	}{
+		{
+			name: "should answer from the first provider",
+			providers: func(t *testing.T) []CEPProvider {
+				viaCep := NewViaCepProvider(&http.Client{})
+				viaCep.baseURL = newTestServer(t, http.StatusOK, viaCepOK, 0)
+				return []CEPProvider{viaCep}
+			},
+			expectedCity:     "São Paulo",
+			expectedProvider: "viacep",
+		},
+		{
+			name: "should fall back when a provider returns 5xx",
+			providers: func(t *testing.T) []CEPProvider {
+				viaCep := NewViaCepProvider(&http.Client{})
+				viaCep.baseURL = newTestServer(t, http.StatusBadGateway, "", 0)
+				brasilAPI := NewBrasilAPIProvider(&http.Client{})
+				brasilAPI.baseURL = newTestServer(t, http.StatusOK, brasilAPIOK, 0)
+				return []CEPProvider{viaCep, brasilAPI}
+			},
+			expectedCity:     "São Paulo",
+			expectedProvider: "brasilapi",
+		},
+		{
+			name: "should fall back when a provider returns a malformed body",
+			providers: func(t *testing.T) []CEPProvider {
+				viaCep := NewViaCepProvider(&http.Client{})
+				viaCep.baseURL = newTestServer(t, http.StatusOK, "<html>", 0)
+				openCep := NewOpenCepProvider(&http.Client{})
+				openCep.baseURL = newTestServer(t, http.StatusOK, viaCepOK, 0)
+				return []CEPProvider{viaCep, openCep}
+			},
+			expectedCity:     "São Paulo",
+			expectedProvider: "opencep",
+		},
+		{
+			name: "should fall back when a provider times out",
+			providers: func(t *testing.T) []CEPProvider {
+				viaCep := NewViaCepProvider(&http.Client{})
+				viaCep.baseURL = newTestServer(t, http.StatusOK, viaCepOK, time.Second)
+				brasilAPI := NewBrasilAPIProvider(&http.Client{})
+				brasilAPI.baseURL = newTestServer(t, http.StatusOK, brasilAPIOK, 0)
+				return []CEPProvider{viaCep, brasilAPI}
+			},
+			expectedCity:     "São Paulo",
+			expectedProvider: "brasilapi",
+		},
+		{
+			name: "should stop on not found",
+			providers: func(t *testing.T) []CEPProvider {
+				viaCep := NewViaCepProvider(&http.Client{})
+				viaCep.baseURL = newTestServer(t, http.StatusOK, `{"erro":true}`, 0)
+				brasilAPI := NewBrasilAPIProvider(&http.Client{})
+				brasilAPI.baseURL = newTestServer(t, http.StatusOK, brasilAPIOK, 0)
+				return []CEPProvider{viaCep, brasilAPI}
+			},
+			expectedErr: exceptions.ErrCannotFindZipcode,
+		},
+		{
+			name: "should return unavailable when every provider fails",
+			providers: func(t *testing.T) []CEPProvider {
+				viaCep := NewViaCepProvider(&http.Client{})
+				viaCep.baseURL = newTestServer(t, http.StatusInternalServerError, "", 0)
+				openCep := NewOpenCepProvider(&http.Client{})
+				openCep.baseURL = newTestServer(t, http.StatusServiceUnavailable, "", 0)
+				return []CEPProvider{viaCep, openCep}
+			},
+			expectedErr: exceptions.ErrCEPServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			service := NewViaCepService(logger.Discard(), tc.providers(t)...)
+			address, err := service.GetCEPData(suite.ctx, "01001-000")
+			suite.Equal(tc.expectedErr, err)
+			if tc.expectedErr != nil {
+				suite.Nil(address)
+				return
+			}
+			suite.Equal(tc.expectedCity, address.Localidade)
+			suite.Equal(tc.expectedProvider, address.Provider)
+		})
+	}
+}
+
+func (suite *ViaCepServiceSuite) TestGetCEPDataSpans() {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	viaCep := NewViaCepProvider(&http.Client{})
+	viaCep.baseURL = newTestServer(suite.T(), http.StatusBadGateway, "", 0)
+	brasilAPI := NewBrasilAPIProvider(&http.Client{})
+	brasilAPI.baseURL = newTestServer(suite.T(), http.StatusOK, `{"cep":"01001000","state":"SP","city":"São Paulo"}`, 0)
+
+	_, err := NewViaCepService(logger.Discard(), viaCep, brasilAPI).GetCEPData(suite.ctx, "01001-000")
+	suite.Require().NoError(err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	suite.Equal(codes.Error, spans["CEPProvider.viacep"].Status().Code)
+	suite.Contains(spans["CEPProvider.viacep"].Attributes(), telemetry.CEPProvider("viacep"))
+	suite.Equal(codes.Unset, spans["CEPProvider.brasilapi"].Status().Code)
+
+	service := spans["ViaCEPService.GetCEPData"]
+	suite.Equal(codes.Unset, service.Status().Code)
+	suite.Subset(service.Attributes(), append(
+		telemetry.Address("São Paulo", "SP", ""),
+		telemetry.CEP("01001-000"),
+		telemetry.CEPProvider("brasilapi"),
+	))
+}
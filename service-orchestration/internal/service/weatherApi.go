@@ -3,57 +3,80 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/httpclient"
 	"github.com/spf13/viper"
-	"go.opentelemetry.io/otel"
 )
 
+// WeatherObservation is the provider neutral reading every weather adapter
+// maps its payload into.
+type WeatherObservation struct {
+	Provider   string    `json:"provider"`
+	Location   string    `json:"location"`
+	TempC      float64   `json:"temp_c"`
+	ObservedAt time.Time `json:"observed_at"`
+	// Stale is set when the observation is served from cache past its TTL.
+	Stale bool `json:"stale,omitempty"`
+}
+
 type WeatherAPIResponse struct {
+	Location struct {
+		Name string `json:"name"`
+	} `json:"location"`
 	Current struct {
-		TempC float64 `json:"temp_c"`
+		LastUpdatedEpoch int64   `json:"last_updated_epoch"`
+		TempC            float64 `json:"temp_c"`
 	} `json:"current"`
 }
 
 type WeatherApiServiceInterface interface {
-	GetWeatherData(ctx context.Context, location string) (*WeatherAPIResponse, error)
+	GetWeatherData(ctx context.Context, location string) (*WeatherObservation, error)
 }
 
 type WeatherApiService struct {
-	client *http.Client
+	client  *http.Client
+	baseURL string
 }
 
 func NewWeatherApiService() *WeatherApiService {
 	return &WeatherApiService{
-		client: &http.Client{},
+		client:  httpclient.NewHTTPClient(WeatherProviderWeatherAPI),
+		baseURL: "http://api.weatherapi.com/v1/current.json",
 	}
 }
 
-func (s *WeatherApiService) GetWeatherData(ctx context.Context, location string) (*WeatherAPIResponse, error) {
-	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
-	ctx, span := tracer.Start(ctx, "WeatherAPI.GetWeatherData")
-	defer span.End()
+func (s *WeatherApiService) GetWeatherData(ctx context.Context, location string) (*WeatherObservation, error) {
+	return traceWeatherData(ctx, "WeatherAPI.GetWeatherData", WeatherProviderWeatherAPI, location, s.getWeatherData)
+}
 
-	WEATHER_API_KEY := viper.GetString("WEATHER_API_KEY")
-	urlString := fmt.Sprintf("http://api.weatherapi.com/v1/current.json?key=%s&q=%s&aqi=no", WEATHER_API_KEY, url.QueryEscape(location))
+func (s *WeatherApiService) HealthURL() string {
+	return s.currentURL(probeLocation)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+func (s *WeatherApiService) getWeatherData(ctx context.Context, location string) (*WeatherObservation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.currentURL(location), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	res, err := s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, httpclient.Redact(err)
 	}
 
 	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return nil, errors.New("cannot find weather data")
+	// WeatherAPI answers 400 for locations it does not know.
+	if res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusNotFound {
+		return nil, exceptions.ErrCannotFindWeatherData
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, &upstreamStatusError{provider: WeatherProviderWeatherAPI, statusCode: res.StatusCode}
 	}
 
 	var weatherAPIResponse WeatherAPIResponse
@@ -62,5 +85,15 @@ func (s *WeatherApiService) GetWeatherData(ctx context.Context, location string)
 		return nil, err
 	}
 
-	return &weatherAPIResponse, nil
+	return &WeatherObservation{
+		Provider:   WeatherProviderWeatherAPI,
+		Location:   weatherAPIResponse.Location.Name,
+		TempC:      weatherAPIResponse.Current.TempC,
+		ObservedAt: time.Unix(weatherAPIResponse.Current.LastUpdatedEpoch, 0).UTC(),
+	}, nil
+}
+
+func (s *WeatherApiService) currentURL(location string) string {
+	WEATHER_API_KEY := viper.GetString("WEATHER_API_KEY")
+	return fmt.Sprintf("%s?key=%s&q=%s&aqi=no", s.baseURL, WEATHER_API_KEY, url.QueryEscape(location))
 }
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	WeatherProviderWeatherAPI     = "weatherapi"
+	WeatherProviderOpenMeteo      = "openmeteo"
+	WeatherProviderOpenWeatherMap = "openweathermap"
+)
+
+var weatherProviderRegistry = map[string]func() WeatherApiServiceInterface{
+	WeatherProviderWeatherAPI:     func() WeatherApiServiceInterface { return NewWeatherApiService() },
+	WeatherProviderOpenMeteo:      func() WeatherApiServiceInterface { return NewOpenMeteoService() },
+	WeatherProviderOpenWeatherMap: func() WeatherApiServiceInterface { return NewOpenWeatherMapService() },
+}
+
+// NewWeatherProvider builds the weather adapter registered under name,
+// defaulting to WeatherAPI when name is empty.
+func NewWeatherProvider(name string) (WeatherApiServiceInterface, error) {
+	if name == "" {
+		name = WeatherProviderWeatherAPI
+	}
+
+	factory, ok := weatherProviderRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown weather provider %q", name)
+	}
+
+	return factory(), nil
+}
+
+// traceWeatherData runs fetch in a span named spanName, describing the
+// location asked to provider and the temperature it answered with.
+func traceWeatherData(
+	ctx context.Context,
+	spanName, provider, location string,
+	fetch func(ctx context.Context, location string) (*WeatherObservation, error),
+) (*WeatherObservation, error) {
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(ctx, spanName,
+		trace.WithAttributes(telemetry.City(location), telemetry.WeatherProvider(provider)),
+	)
+	defer span.End()
+
+	observation, err := fetch(ctx, location)
+	if err != nil {
+		err = weatherError(err)
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(telemetry.TemperatureCelsius(observation.TempC))
+	return observation, nil
+}
+
+// weatherError reports the failures of a provider that are not already
+// classified as exceptions.ErrWeatherServiceUnavailable.
+func weatherError(err error) error {
+	var e *exceptions.Error
+	if errors.As(err, &e) || errors.Is(err, breaker.ErrOpen) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	return exceptions.Wrap(exceptions.ErrWeatherServiceUnavailable, err)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+)
+
+type WeatherProviderSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func TestWeatherProviderStart(t *testing.T) {
+	suite.Run(t, new(WeatherProviderSuite))
+}
+
+func (suite *WeatherProviderSuite) SetupTest() {
+	suite.ctx = context.Background()
+}
+
+func (suite *WeatherProviderSuite) newServer(routes map[string]string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	suite.T().Cleanup(server.Close)
+	return server.URL
+}
+
+func (suite *WeatherProviderSuite) TestNewWeatherProvider() {
+	provider, err := NewWeatherProvider("")
+	suite.NoError(err)
+	suite.IsType(&WeatherApiService{}, provider)
+
+	provider, err = NewWeatherProvider(WeatherProviderOpenMeteo)
+	suite.NoError(err)
+	suite.IsType(&OpenMeteoService{}, provider)
+
+	_, err = NewWeatherProvider("unknown")
+	suite.Error(err)
+}
+
+func (suite *WeatherProviderSuite) TestHealthURL() {
+	for _, name := range []string{WeatherProviderWeatherAPI, WeatherProviderOpenMeteo, WeatherProviderOpenWeatherMap} {
+		provider, err := NewWeatherProvider(name)
+		suite.Require().NoError(err)
+		suite.Implements((*HealthProber)(nil), provider, name)
+	}
+
+	viper.Set("WEATHER_API_KEY", "secret")
+	suite.T().Cleanup(viper.Reset)
+	suite.Equal("http://api.weatherapi.com/v1/current.json?key=secret&q=S%C3%A3o+Paulo&aqi=no", NewWeatherApiService().HealthURL())
+}
+
+func (suite *WeatherProviderSuite) TestWeatherAPI() {
+	url := suite.newServer(map[string]string{
+		"/current.json": `{"location":{"name":"Sao Paulo"},"current":{"last_updated_epoch":1700000000,"temp_c":21.5}}`,
+	})
+	service := NewWeatherApiService()
+	service.baseURL = url + "/current.json"
+
+	observation, err := service.GetWeatherData(suite.ctx, "São Paulo")
+	suite.NoError(err)
+	suite.Equal(&WeatherObservation{
+		Provider:   WeatherProviderWeatherAPI,
+		Location:   "Sao Paulo",
+		TempC:      21.5,
+		ObservedAt: time.Unix(1700000000, 0).UTC(),
+	}, observation)
+
+	service.baseURL = url + "/missing"
+	_, err = service.GetWeatherData(suite.ctx, "São Paulo")
+	suite.Equal(exceptions.ErrCannotFindWeatherData, err)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer failing.Close()
+	service.baseURL = failing.URL
+	_, err = service.GetWeatherData(suite.ctx, "São Paulo")
+	suite.ErrorIs(err, exceptions.ErrWeatherServiceUnavailable)
+	suite.EqualError(err, "weather service unavailable: weatherapi responded with status 401")
+}
+
+func (suite *WeatherProviderSuite) TestOpenMeteo() {
+	url := suite.newServer(map[string]string{
+		"/search":   `{"results":[{"name":"São Paulo","latitude":-23.5,"longitude":-46.6}]}`,
+		"/forecast": `{"current":{"time":1700000000,"temperature_2m":19.2}}`,
+	})
+	service := NewOpenMeteoService()
+	service.geocodingURL = url + "/search"
+	service.forecastURL = url + "/forecast"
+
+	observation, err := service.GetWeatherData(suite.ctx, "São Paulo")
+	suite.NoError(err)
+	suite.Equal(&WeatherObservation{
+		Provider:   WeatherProviderOpenMeteo,
+		Location:   "São Paulo",
+		TempC:      19.2,
+		ObservedAt: time.Unix(1700000000, 0).UTC(),
+	}, observation)
+
+	service.geocodingURL = suite.newServer(map[string]string{"/search": `{}`}) + "/search"
+	_, err = service.GetWeatherData(suite.ctx, "Nowhere")
+	suite.Equal(exceptions.ErrCannotFindWeatherData, err)
+}
+
+func (suite *WeatherProviderSuite) TestOpenWeatherMap() {
+	url := suite.newServer(map[string]string{
+		"/weather": `{"name":"São Paulo","dt":1700000000,"main":{"temp":23.4}}`,
+	})
+	service := NewOpenWeatherMapService()
+	service.baseURL = url + "/weather"
+
+	observation, err := service.GetWeatherData(suite.ctx, "São Paulo")
+	suite.NoError(err)
+	suite.Equal(&WeatherObservation{
+		Provider:   WeatherProviderOpenWeatherMap,
+		Location:   "São Paulo",
+		TempC:      23.4,
+		ObservedAt: time.Unix(1700000000, 0).UTC(),
+	}, observation)
+
+	service.baseURL = url + "/missing"
+	_, err = service.GetWeatherData(suite.ctx, "São Paulo")
+	suite.Equal(exceptions.ErrCannotFindWeatherData, err)
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/kameikay/service-orchestration/internal/service"
+	"github.com/spf13/viper"
+)
+
+// defaultConsensusMaxDeviation is how far, in Celsius, a reading may sit from
+// the median before it is discarded as an outlier.
+const defaultConsensusMaxDeviation = 3.0
+
+type Reading struct {
+	Provider string  `json:"provider"`
+	TempC    float64 `json:"temp_C"`
+	Outlier  bool    `json:"outlier"`
+	Stale    bool    `json:"stale,omitempty"`
+}
+
+// consensus queries every weather service concurrently, discards readings too
+// far from the median and returns the median of the remaining ones, along
+// with the stale observations that contributed to it.
+func (u *GetTemperaturesUseCase) consensus(ctx context.Context, location string) (float64, []Reading, []*service.WeatherObservation, error) {
+	observations := make([]*service.WeatherObservation, len(u.weatherApiServices))
+	errs := make([]error, len(u.weatherApiServices))
+
+	var wg sync.WaitGroup
+	for i, weatherApiService := range u.weatherApiServices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			observation, err := weatherApiService.GetWeatherData(ctx, location)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			observations[i] = observation
+		}()
+	}
+	wg.Wait()
+
+	var contributed []Reading
+	var stale []*service.WeatherObservation
+	for _, observation := range observations {
+		if observation == nil {
+			continue
+		}
+		contributed = append(contributed, Reading{Provider: observation.Provider, TempC: observation.TempC, Stale: observation.Stale})
+		if observation.Stale {
+			stale = append(stale, observation)
+		}
+	}
+
+	if len(contributed) == 0 {
+		for _, err := range errs {
+			if err != nil {
+				return 0, nil, nil, err
+			}
+		}
+	}
+
+	maxDeviation := viper.GetFloat64("WEATHER_CONSENSUS_MAX_DEVIATION")
+	if maxDeviation <= 0 {
+		maxDeviation = defaultConsensusMaxDeviation
+	}
+
+	tempC := discardOutliers(contributed, maxDeviation)
+	for _, reading := range contributed {
+		if reading.Outlier {
+			u.logger.WarnContext(ctx, "discarded outlier weather reading", "provider", reading.Provider, "temp_c", reading.TempC, "consensus_temp_c", tempC)
+		}
+	}
+
+	return tempC, contributed, stale, nil
+}
+
+// discardOutliers flags the readings further than maxDeviation from the
+// median and returns the median of the rest. When no reading survives, as
+// with two providers that disagree, the plain median is kept.
+func discardOutliers(readings []Reading, maxDeviation float64) float64 {
+	values := make([]float64, len(readings))
+	for i, reading := range readings {
+		values[i] = reading.TempC
+	}
+	center := median(values)
+
+	var kept []float64
+	for i := range readings {
+		deviation := readings[i].TempC - center
+		if deviation > maxDeviation || deviation < -maxDeviation {
+			readings[i].Outlier = true
+			continue
+		}
+		kept = append(kept, readings[i].TempC)
+	}
+
+	if len(kept) == 0 {
+		for i := range readings {
+			readings[i].Outlier = false
+		}
+		return center
+	}
+
+	return median(kept)
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -2,51 +2,155 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"time"
 
 	"github.com/kameikay/service-orchestration/internal/service"
+	"github.com/kameikay/shared/pkg/deadline"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultCEPBudgetShare is the fraction of the request budget the zipcode
+// lookup may use, the rest being left for the weather lookup.
+const defaultCEPBudgetShare = 0.4
+
+// errNoWeatherProvider is returned when the use case was built without any
+// weather service, which the server refuses to start with.
+var errNoWeatherProvider = errors.New("no weather provider configured")
+
 type GetTemperaturesUseCase struct {
-	viaCepService     service.ViaCepServiceInterface
-	weatherApiService service.WeatherApiServiceInterface
+	logger             *slog.Logger
+	viaCepService      service.ViaCepServiceInterface
+	weatherApiServices []service.WeatherApiServiceInterface
 }
 
 type Response struct {
-	City  string  `json:"city"`
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	City                  string    `json:"city"`
+	TempC                 float64   `json:"temp_C"`
+	TempF                 float64   `json:"temp_F"`
+	TempK                 float64   `json:"temp_K"`
+	Sources               []Reading `json:"sources,omitempty"`
+	Stale                 bool      `json:"stale,omitempty"`
+	ObservationAgeSeconds int64     `json:"observation_age_seconds,omitempty"`
 }
 
+// NewGetTemperatureUseCase builds the use case. With a single weather service
+// the temperature comes straight from it; with several, they are queried
+// concurrently and combined into a consensus reading.
 func NewGetTemperatureUseCase(
+	logger *slog.Logger,
 	viaCepService service.ViaCepServiceInterface,
-	weatherApiService service.WeatherApiServiceInterface,
+	weatherApiServices ...service.WeatherApiServiceInterface,
 ) *GetTemperaturesUseCase {
 	return &GetTemperaturesUseCase{
-		viaCepService:     viaCepService,
-		weatherApiService: weatherApiService,
+		logger:             logger,
+		viaCepService:      viaCepService,
+		weatherApiServices: weatherApiServices,
 	}
 }
 
 func (u *GetTemperaturesUseCase) Execute(ctx context.Context, cep string) (Response, error) {
-	cepData, err := u.viaCepService.GetCEPData(ctx, cep)
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(ctx, "GetTemperaturesUseCase.Execute", trace.WithAttributes(telemetry.CEP(cep)))
+	defer span.End()
+	deadline.Annotate(ctx, span)
+
+	if len(u.weatherApiServices) == 0 {
+		err := exceptions.Wrap(exceptions.ErrWeatherServiceUnavailable, errNoWeatherProvider)
+		telemetry.RecordError(span, err)
+		return Response{}, err
+	}
+
+	cepData, err := u.getCEPData(ctx, cep)
 	if err != nil {
+		err = budgetError(ctx, err)
+		telemetry.RecordError(span, err)
 		return Response{}, err
 	}
+	span.SetAttributes(telemetry.CEPProvider(cepData.Provider))
+	span.SetAttributes(telemetry.Address(cepData.Localidade, cepData.Uf, cepData.Ibge)...)
+
+	if len(u.weatherApiServices) > 1 {
+		tempC, readings, stale, err := u.consensus(ctx, cepData.Localidade)
+		if err != nil {
+			err = budgetError(ctx, err)
+			telemetry.RecordError(span, err)
+			return Response{}, err
+		}
 
-	weatherData, err := u.weatherApiService.GetWeatherData(ctx, cepData.Localidade)
+		response := newResponse(cepData.Localidade, tempC)
+		response.Sources = readings
+		markStale(&response, stale...)
+		span.SetAttributes(telemetry.WeatherProvider("consensus"), telemetry.WeatherStale(response.Stale))
+		span.SetAttributes(telemetry.Temperature(response.TempC, response.TempF, response.TempK)...)
+		u.logger.DebugContext(ctx, "temperature resolved by consensus", "city", response.City, "temp_c", response.TempC, "sources", len(readings), "stale", response.Stale)
+		return response, nil
+	}
+
+	weatherData, err := u.weatherApiServices[0].GetWeatherData(ctx, cepData.Localidade)
 	if err != nil {
+		err = budgetError(ctx, err)
+		telemetry.RecordError(span, err)
 		return Response{}, err
 	}
 
-	tempF := weatherData.Current.TempC*1.8 + 32
-	tempK := weatherData.Current.TempC + 273
+	response := newResponse(cepData.Localidade, weatherData.TempC)
+	if weatherData.Stale {
+		markStale(&response, weatherData)
+	}
+	span.SetAttributes(telemetry.WeatherProvider(weatherData.Provider), telemetry.WeatherStale(response.Stale))
+	span.SetAttributes(telemetry.Temperature(response.TempC, response.TempF, response.TempK)...)
+	u.logger.DebugContext(ctx, "temperature resolved", "city", response.City, "temp_c", response.TempC, "provider", weatherData.Provider, "stale", response.Stale)
+	return response, nil
+}
+
+// getCEPData looks the zipcode up within its share of the request budget.
+func (u *GetTemperaturesUseCase) getCEPData(ctx context.Context, cep string) (*service.ViaCEPResponse, error) {
+	share := viper.GetFloat64("CEP_BUDGET_SHARE")
+	if share <= 0 {
+		share = defaultCEPBudgetShare
+	}
+
+	ctx, cancel := deadline.Share(ctx, share)
+	defer cancel()
+
+	return u.viaCepService.GetCEPData(ctx, cep)
+}
+
+// budgetError reports err as exceptions.ErrDeadlineExceeded when it was
+// caused by the request budget running out.
+func budgetError(ctx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return exceptions.ErrDeadlineExceeded
+	}
+	return err
+}
+
+// markStale flags the response as built from stale observations, reporting
+// the age of the oldest one.
+func markStale(response *Response, observations ...*service.WeatherObservation) {
+	for _, observation := range observations {
+		response.Stale = true
+		age := int64(time.Since(observation.ObservedAt).Seconds())
+		if age > response.ObservationAgeSeconds {
+			response.ObservationAgeSeconds = age
+		}
+	}
+}
+
+func newResponse(city string, tempC float64) Response {
+	tempF := tempC*1.8 + 32
+	tempK := tempC + 273
 
 	return Response{
-		City:  cepData.Localidade,
-		TempC: weatherData.Current.TempC,
+		City:  city,
+		TempC: tempC,
 		TempF: tempF,
 		TempK: tempK,
-	}, nil
-
+	}
 }
@@ -3,11 +3,15 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/kameikay/service-orchestration/internal/service"
 	mock "github.com/kameikay/service-orchestration/internal/service/mocks"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/logger"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -35,7 +39,7 @@ func (suite *GetTemperaturesUseCaseSuite) SetupTest() {
 }
 
 func (suite *GetTemperaturesUseCaseSuite) TestNewGetCEPDataUseCase() {
-	useCase := NewGetTemperatureUseCase(suite.viaCepService, suite.weatherApiService)
+	useCase := NewGetTemperatureUseCase(logger.Discard(), suite.viaCepService, suite.weatherApiService)
 	suite.NotNil(useCase)
 }
 
@@ -51,15 +55,11 @@ func (suite *GetTemperaturesUseCaseSuite) TestExecute() {
 			name: "should return correct temperatures",
 			cep:  "12345678",
 			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
-				viaCepService.EXPECT().GetCEPData(suite.ctx, "12345678").Return(&service.ViaCEPResponse{
+				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345678").Return(&service.ViaCEPResponse{
 					Localidade: "São Paulo",
 				}, nil)
-				weatherApiService.EXPECT().GetWeatherData(suite.ctx, "São Paulo").Return(&service.WeatherAPIResponse{
-					Current: struct {
-						TempC float64 `json:"temp_c"`
-					}{
-						TempC: 25,
-					},
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+					TempC: 25,
 				}, nil)
 			},
 			expectedResp: Response{
@@ -74,8 +74,8 @@ func (suite *GetTemperaturesUseCaseSuite) TestExecute() {
 			name: "should return error when Via Cep Service returns error",
 			cep:  "12345678",
 			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
-				viaCepService.EXPECT().GetCEPData(suite.ctx, "12345678").Return(nil, errors.New("error"))
-				weatherApiService.EXPECT().GetWeatherData(suite.ctx, "São Paulo").Times(0)
+				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345678").Return(nil, errors.New("error"))
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Times(0)
 			},
 			expectedResp: Response{},
 			expectedErr:  errors.New("error"),
@@ -84,20 +84,32 @@ func (suite *GetTemperaturesUseCaseSuite) TestExecute() {
 			name: "should return error when Weather API Service returns error",
 			cep:  "12345678",
 			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
-				viaCepService.EXPECT().GetCEPData(suite.ctx, "12345678").Return(&service.ViaCEPResponse{
+				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345678").Return(&service.ViaCEPResponse{
 					Localidade: "São Paulo",
 				}, nil)
-				weatherApiService.EXPECT().GetWeatherData(suite.ctx, "São Paulo").Return(nil, errors.New("error"))
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(nil, errors.New("error"))
 			},
 			expectedResp: Response{},
 			expectedErr:  errors.New("error"),
 		},
+		{
+			name: "should return deadline error when the request budget runs out",
+			cep:  "12345678",
+			expectations: func(viaCepService *mock.MockViaCepServiceInterface, weatherApiService *mock.MockWeatherApiServiceInterface) {
+				viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345678").Return(&service.ViaCEPResponse{
+					Localidade: "São Paulo",
+				}, nil)
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(nil, fmt.Errorf("weatherapi: %w", context.DeadlineExceeded))
+			},
+			expectedResp: Response{},
+			expectedErr:  exceptions.ErrDeadlineExceeded,
+		},
 	}
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
 			tc.expectations(suite.viaCepService, suite.weatherApiService)
-			useCase := NewGetTemperatureUseCase(suite.viaCepService, suite.weatherApiService)
+			useCase := NewGetTemperatureUseCase(logger.Discard(), suite.viaCepService, suite.weatherApiService)
 			res, err := useCase.Execute(suite.ctx, tc.cep)
 			suite.Equal(tc.expectedResp, res)
 			suite.Equal(tc.expectedErr, err)
@@ -105,3 +117,132 @@ func (suite *GetTemperaturesUseCaseSuite) TestExecute() {
 	}
 
 }
+
+func (suite *GetTemperaturesUseCaseSuite) TestExecuteWithoutWeatherServices() {
+	suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), gomock.Any()).Times(0)
+
+	useCase := NewGetTemperatureUseCase(logger.Discard(), suite.viaCepService)
+	_, err := useCase.Execute(suite.ctx, "12345678")
+
+	suite.ErrorIs(err, exceptions.ErrWeatherServiceUnavailable)
+	suite.ErrorIs(err, errNoWeatherProvider)
+}
+
+func (suite *GetTemperaturesUseCaseSuite) TestExecuteConsensus() {
+	weatherApiService := mock.NewMockWeatherApiServiceInterface(suite.ctrl)
+	otherWeatherApiService := mock.NewMockWeatherApiServiceInterface(suite.ctrl)
+
+	testCases := []struct {
+		name         string
+		expectations func()
+		expectedResp Response
+		expectedErr  error
+	}{
+		{
+			name: "should return the median and discard outliers",
+			expectations: func() {
+				suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345678").Return(&service.ViaCEPResponse{
+					Localidade: "São Paulo",
+				}, nil)
+				suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+					Provider: "weatherapi", TempC: 35,
+				}, nil)
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+					Provider: "openmeteo", TempC: 20,
+				}, nil)
+				otherWeatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+					Provider: "openweathermap", TempC: 22,
+				}, nil)
+			},
+			expectedResp: Response{
+				City:  "São Paulo",
+				TempC: 21,
+				TempF: 69.8,
+				TempK: 294,
+				Sources: []Reading{
+					{Provider: "weatherapi", TempC: 35, Outlier: true},
+					{Provider: "openmeteo", TempC: 20},
+					{Provider: "openweathermap", TempC: 22},
+				},
+			},
+		},
+		{
+			name: "should ignore providers that fail",
+			expectations: func() {
+				suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345678").Return(&service.ViaCEPResponse{
+					Localidade: "São Paulo",
+				}, nil)
+				suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(nil, errors.New("error"))
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+					Provider: "openmeteo", TempC: 20,
+				}, nil)
+				otherWeatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+					Provider: "openweathermap", TempC: 22,
+				}, nil)
+			},
+			expectedResp: Response{
+				City:  "São Paulo",
+				TempC: 21,
+				TempF: 69.8,
+				TempK: 294,
+				Sources: []Reading{
+					{Provider: "openmeteo", TempC: 20},
+					{Provider: "openweathermap", TempC: 22},
+				},
+			},
+		},
+		{
+			name: "should return error when every provider fails",
+			expectations: func() {
+				suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345678").Return(&service.ViaCEPResponse{
+					Localidade: "São Paulo",
+				}, nil)
+				suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(nil, errors.New("error"))
+				weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(nil, errors.New("error"))
+				otherWeatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(nil, errors.New("error"))
+			},
+			expectedResp: Response{},
+			expectedErr:  errors.New("error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			tc.expectations()
+			useCase := NewGetTemperatureUseCase(logger.Discard(), suite.viaCepService, suite.weatherApiService, weatherApiService, otherWeatherApiService)
+			res, err := useCase.Execute(suite.ctx, "12345678")
+			suite.InDelta(tc.expectedResp.TempF, res.TempF, 0.0001)
+			res.TempF = tc.expectedResp.TempF
+			suite.Equal(tc.expectedResp, res)
+			suite.Equal(tc.expectedErr, err)
+		})
+	}
+}
+
+func (suite *GetTemperaturesUseCaseSuite) TestExecuteStale() {
+	suite.viaCepService.EXPECT().GetCEPData(gomock.Any(), "12345678").Return(&service.ViaCEPResponse{
+		Localidade: "São Paulo",
+	}, nil)
+	suite.weatherApiService.EXPECT().GetWeatherData(gomock.Any(), "São Paulo").Return(&service.WeatherObservation{
+		TempC:      25,
+		ObservedAt: time.Now().Add(-10 * time.Minute),
+		Stale:      true,
+	}, nil)
+
+	useCase := NewGetTemperatureUseCase(logger.Discard(), suite.viaCepService, suite.weatherApiService)
+	res, err := useCase.Execute(suite.ctx, "12345678")
+	suite.NoError(err)
+	suite.True(res.Stale)
+	suite.InDelta(600, res.ObservationAgeSeconds, 1)
+}
+
+func (suite *GetTemperaturesUseCaseSuite) TestDiscardOutliers() {
+	readings := []Reading{{TempC: 10}, {TempC: 30}}
+	suite.Equal(20.0, discardOutliers(readings, 3))
+	suite.False(readings[0].Outlier)
+	suite.False(readings[1].Outlier)
+
+	readings = []Reading{{TempC: 10}, {TempC: 11}, {TempC: 12}, {TempC: 40}}
+	suite.Equal(11.0, discardOutliers(readings, 3))
+	suite.True(readings[3].Outlier)
+}
@@ -1,8 +0,0 @@
-package exceptions
-
-import "errors"
-
-var (
-	ErrInvalidCEP        = errors.New("invalid zipcode")
-	ErrCannotFindZipcode = errors.New("can not find zipcode")
-)
@@ -0,0 +1,33 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+)
+
+var loaded atomic.Bool
+
+func LoadConfig(path string) error {
+	viper.SetConfigName(".env")
+	viper.SetConfigType("env")
+	viper.AddConfigPath(path)
+	err := viper.ReadInConfig()
+	if err != nil {
+		return err
+	}
+
+	viper.AutomaticEnv()
+	loaded.Store(true)
+	return nil
+}
+
+// CheckConfig is a readiness check failing until LoadConfig has succeeded.
+func CheckConfig(context.Context) error {
+	if !loaded.Load() {
+		return errors.New("configuration not loaded")
+	}
+	return nil
+}
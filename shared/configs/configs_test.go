@@ -0,0 +1,25 @@
+package configs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConfig(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("SERVICE_NAME=test\n"), 0o600))
+
+	assert.Error(t, LoadConfig(t.TempDir()))
+	assert.EqualError(t, CheckConfig(context.Background()), "configuration not loaded")
+
+	require.NoError(t, LoadConfig(dir))
+	assert.NoError(t, CheckConfig(context.Background()))
+}
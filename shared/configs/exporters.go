@@ -0,0 +1,305 @@
+package configs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Exporters selected by OTEL_TRACES_EXPORTER, OTEL_METRICS_EXPORTER and
+// OTEL_LOGS_EXPORTER. Zipkin only takes traces and stdout is not offered for
+// logs, which are already written there.
+const (
+	ExporterOTLPGRPC = "otlpgrpc"
+	ExporterOTLPHTTP = "otlphttp"
+	ExporterZipkin   = "zipkin"
+	ExporterStdout   = "stdout"
+	ExporterNone     = "none"
+)
+
+// exporters builds the exporter of each signal from the OTEL_EXPORTER_*
+// settings. The OTLP/gRPC exporters share a single connection to the
+// collector, which is established in the background: the services start
+// whether or not the collector is reachable, and spans wait in the batch
+// processor queue while the export is retried.
+type exporters struct {
+	endpoint string
+	// httpEndpoint is the address of the OTLP/HTTP receiver of the collector.
+	httpEndpoint string
+	headers      map[string]string
+	// tls is nil when the connections to the backend are not encrypted.
+	tls   *tls.Config
+	retry time.Duration
+	conn  *grpc.ClientConn
+}
+
+func newExporters() (*exporters, error) {
+	viper.SetDefault("OTEL_EXPORTER_INSECURE", true)
+	viper.SetDefault("OTEL_EXPORTER_RETRY_MAX_ELAPSED", time.Minute)
+
+	e := &exporters{
+		endpoint:     viper.GetString("OTEL_COLLECTOR_ADDR"),
+		httpEndpoint: viper.GetString("OTEL_COLLECTOR_HTTP_ADDR"),
+		headers:      parsePairs(viper.GetString("OTEL_EXPORTER_HEADERS")),
+		retry:        viper.GetDuration("OTEL_EXPORTER_RETRY_MAX_ELAPSED"),
+	}
+	if e.httpEndpoint == "" {
+		e.httpEndpoint = defaultHTTPEndpoint(e.endpoint)
+	}
+
+	if !viper.GetBool("OTEL_EXPORTER_INSECURE") {
+		var err error
+		e.tls, err = newTLSConfig(
+			viper.GetString("OTEL_EXPORTER_CA_FILE"),
+			viper.GetString("OTEL_EXPORTER_CERT_FILE"),
+			viper.GetString("OTEL_EXPORTER_KEY_FILE"),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// defaultHTTPEndpoint points at the default OTLP/HTTP port of the collector
+// listening for gRPC on addr.
+func defaultHTTPEndpoint(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.JoinHostPort(host, "4318")
+}
+
+// grpcConn returns the connection to the collector, creating it on first
+// use. Creating it does not wait for the collector to answer.
+func (e *exporters) grpcConn() (*grpc.ClientConn, error) {
+	if e.conn != nil {
+		return e.conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if e.tls != nil {
+		creds = credentials.NewTLS(e.tls)
+	}
+
+	conn, err := grpc.NewClient(e.endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
+	}
+
+	e.conn = conn
+	return conn, nil
+}
+
+// traceExporter returns the exporter selected by OTEL_TRACES_EXPORTER, or nil
+// when traces are not exported.
+func (e *exporters) traceExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	viper.SetDefault("OTEL_TRACES_EXPORTER", ExporterOTLPGRPC)
+	viper.SetDefault("ZIPKIN_ENDPOINT", "http://zipkin-all-in-one:9411/api/v2/spans")
+
+	switch name := viper.GetString("OTEL_TRACES_EXPORTER"); name {
+	case ExporterOTLPGRPC:
+		conn, err := e.grpcConn()
+		if err != nil {
+			return nil, err
+		}
+		return otlptracegrpc.New(ctx,
+			otlptracegrpc.WithGRPCConn(conn),
+			otlptracegrpc.WithHeaders(e.headers),
+			otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+				Enabled:         true,
+				InitialInterval: time.Second,
+				MaxInterval:     30 * time.Second,
+				MaxElapsedTime:  e.retry,
+			}),
+		)
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(e.httpEndpoint),
+			otlptracehttp.WithHeaders(e.headers),
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
+				Enabled:         true,
+				InitialInterval: time.Second,
+				MaxInterval:     30 * time.Second,
+				MaxElapsedTime:  e.retry,
+			}),
+		}
+		if e.tls == nil {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(e.tls))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterZipkin:
+		opts := []zipkin.Option{zipkin.WithHeaders(e.headers)}
+		if e.tls != nil {
+			opts = append(opts, zipkin.WithClient(&http.Client{Transport: &http.Transport{TLSClientConfig: e.tls}}))
+		}
+		return zipkin.New(viper.GetString("ZIPKIN_ENDPOINT"), opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", name)
+	}
+}
+
+// metricExporter returns the exporter selected by OTEL_METRICS_EXPORTER, or
+// nil when metrics are not exported.
+func (e *exporters) metricExporter(ctx context.Context) (sdkmetric.Exporter, error) {
+	viper.SetDefault("OTEL_METRICS_EXPORTER", ExporterOTLPGRPC)
+
+	switch name := viper.GetString("OTEL_METRICS_EXPORTER"); name {
+	case ExporterOTLPGRPC:
+		conn, err := e.grpcConn()
+		if err != nil {
+			return nil, err
+		}
+		return otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithGRPCConn(conn), otlpmetricgrpc.WithHeaders(e.headers))
+	case ExporterOTLPHTTP:
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(e.httpEndpoint), otlpmetrichttp.WithHeaders(e.headers)}
+		if e.tls == nil {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		} else {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(e.tls))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdoutmetric.New(stdoutmetric.WithPrettyPrint())
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown metrics exporter %q", name)
+	}
+}
+
+// logExporter returns the exporter selected by OTEL_LOGS_EXPORTER, or nil
+// when logs are not exported.
+func (e *exporters) logExporter(ctx context.Context) (sdklog.Exporter, error) {
+	viper.SetDefault("OTEL_LOGS_EXPORTER", ExporterOTLPGRPC)
+
+	switch name := viper.GetString("OTEL_LOGS_EXPORTER"); name {
+	case ExporterOTLPGRPC:
+		conn, err := e.grpcConn()
+		if err != nil {
+			return nil, err
+		}
+		return otlploggrpc.New(ctx, otlploggrpc.WithGRPCConn(conn), otlploggrpc.WithHeaders(e.headers))
+	case ExporterOTLPHTTP:
+		opts := []otlploghttp.Option{otlploghttp.WithEndpoint(e.httpEndpoint), otlploghttp.WithHeaders(e.headers)}
+		if e.tls == nil {
+			opts = append(opts, otlploghttp.WithInsecure())
+		} else {
+			opts = append(opts, otlploghttp.WithTLSClientConfig(e.tls))
+		}
+		return otlploghttp.New(ctx, opts...)
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown logs exporter %q", name)
+	}
+}
+
+// Check fails while the connection to the collector is failing, and passes
+// when no exporter connects to it over gRPC. An idle connection is asked to
+// reconnect, and passes while it does.
+func (e *exporters) Check(context.Context) error {
+	if e.conn == nil {
+		return nil
+	}
+
+	switch state := e.conn.GetState(); state {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return fmt.Errorf("collector connection is %s", strings.ToLower(state.String()))
+	case connectivity.Idle:
+		e.conn.Connect()
+	}
+
+	return nil
+}
+
+// Shutdown closes the connection to the collector, once the providers using
+// it are shut down.
+func (e *exporters) Shutdown(context.Context) error {
+	if e.conn == nil {
+		return nil
+	}
+	return e.conn.Close()
+}
+
+// newTLSConfig trusts the CA in caFile, or the system roots when it is empty,
+// and presents the client certificate in certFile and keyFile when they are
+// set.
+func newTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read exporter CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("failed to read exporter CA: no certificate found")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load exporter client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// parsePairs reads a list of key=value pairs separated by commas, such as
+// "authorization=Bearer%20token,x-tenant=weather". Values may be percent
+// encoded.
+func parsePairs(value string) map[string]string {
+	pairs := map[string]string{}
+	for _, pair := range splitList(value) {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		if unescaped, err := url.PathUnescape(val); err == nil {
+			val = unescaped
+		}
+		pairs[strings.TrimSpace(key)] = val
+	}
+	return pairs
+}
@@ -0,0 +1,102 @@
+package configs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePairs(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"authorization": "Bearer a=b",
+		"x-tenant":      "weather",
+		"team":          "forecast & alerts",
+	}, parsePairs("authorization=Bearer a=b, x-tenant = weather,invalid,team=forecast%20%26%20alerts"))
+}
+
+func TestNewExportersHTTPEndpoint(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set("OTEL_COLLECTOR_ADDR", "otel-collector:4317")
+	exp, err := newExporters()
+	require.NoError(t, err)
+	assert.Equal(t, "otel-collector:4318", exp.httpEndpoint)
+
+	viper.Set("OTEL_COLLECTOR_HTTP_ADDR", "collector.example:443")
+	exp, err = newExporters()
+	require.NoError(t, err)
+	assert.Equal(t, "collector.example:443", exp.httpEndpoint)
+}
+
+func TestTraceExporter(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	testCases := []struct {
+		name     string
+		exporter string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{name: "otlp over grpc does not wait for the collector", exporter: ExporterOTLPGRPC},
+		{name: "otlp over http", exporter: ExporterOTLPHTTP},
+		{name: "zipkin", exporter: ExporterZipkin},
+		{name: "stdout", exporter: ExporterStdout},
+		{name: "none", exporter: ExporterNone, wantNil: true},
+		{name: "unknown", exporter: "jaeger", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			viper.Set("OTEL_COLLECTOR_ADDR", "127.0.0.1:1")
+			viper.Set("OTEL_TRACES_EXPORTER", tc.exporter)
+
+			exp, err := newExporters()
+			require.NoError(t, err)
+			defer exp.Shutdown(context.Background())
+
+			exporter, err := exp.traceExporter(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantNil, exporter == nil)
+		})
+	}
+}
+
+func TestNewExportersRejectsMissingCA(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set("OTEL_EXPORTER_INSECURE", false)
+	viper.Set("OTEL_EXPORTER_CA_FILE", "/does/not/exist.pem")
+
+	_, err := newExporters()
+	assert.ErrorContains(t, err, "failed to read exporter CA")
+}
+
+func TestExportersCheck(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set("OTEL_COLLECTOR_ADDR", "127.0.0.1:1")
+	viper.Set("OTEL_TRACES_EXPORTER", ExporterOTLPGRPC)
+
+	exp, err := newExporters()
+	require.NoError(t, err)
+	defer exp.Shutdown(context.Background())
+
+	assert.NoError(t, exp.Check(context.Background()), "no connection yet")
+
+	_, err = exp.traceExporter(context.Background())
+	require.NoError(t, err)
+
+	// The connection is idle until the first check asks it to connect.
+	assert.Eventually(t, func() bool {
+		return exp.Check(context.Background()) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.ErrorContains(t, exp.Check(context.Background()), "collector connection is transient_failure")
+}
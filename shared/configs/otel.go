@@ -0,0 +1,138 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/kameikay/shared/pkg/propagators"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// current holds the exporters of the telemetry set up by SetupOTel.
+var current atomic.Pointer[exporters]
+
+// CheckCollector is a readiness check failing while the telemetry exporters
+// cannot reach the collector, or before SetupOTel is called.
+func CheckCollector(ctx context.Context) error {
+	exp := current.Load()
+	if exp == nil {
+		return errors.New("telemetry not set up")
+	}
+	return exp.Check(ctx)
+}
+
+func SetupOTel(logger *slog.Logger) (func(ctx context.Context) error, error) {
+	ctx := context.Background()
+
+	res, err := newResource(ctx)
+	if errors.Is(err, resource.ErrPartialResource) {
+		logger.Warn("some resource attributes could not be detected", "error", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	exp, err := newExporters()
+	if err != nil {
+		return nil, err
+	}
+
+	traceExporter, err := exp.traceExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	sampler, keepErrors := newSampler()
+
+	tracerOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+	}
+	// Baggage is copied onto the spans before any other processor sees them.
+	if keys := splitList(viper.GetString("OTEL_BAGGAGE_SPAN_ATTRIBUTES")); len(keys) > 0 {
+		tracerOptions = append(tracerOptions, sdktrace.WithSpanProcessor(propagators.NewBaggageProcessor(keys...)))
+	}
+	if traceExporter != nil {
+		viper.SetDefault("OTEL_TRACES_QUEUE_SIZE", sdktrace.DefaultMaxQueueSize)
+		bsp := sdktrace.NewBatchSpanProcessor(traceExporter, sdktrace.WithMaxQueueSize(viper.GetInt("OTEL_TRACES_QUEUE_SIZE")))
+		tracerOptions = append(tracerOptions, sdktrace.WithSpanProcessor(newSpanProcessor(bsp, keepErrors)))
+	}
+
+	tp := sdktrace.NewTracerProvider(tracerOptions...)
+	logger.Info("tracer provider configured",
+		"exporter", viper.GetString("OTEL_TRACES_EXPORTER"),
+		"sampler", sampler.Description(),
+	)
+
+	otel.SetTracerProvider(tp)
+
+	viper.SetDefault("OTEL_PROPAGATORS", "tracecontext,baggage")
+	propagator, err := propagators.New(splitList(viper.GetString("OTEL_PROPAGATORS"))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create propagator: %w", err)
+	}
+
+	otel.SetTextMapPropagator(propagator)
+
+	metricExporter, err := exp.metricExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	interval := viper.GetDuration("OTEL_METRIC_EXPORT_INTERVAL")
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	meterOptions := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	if metricExporter != nil {
+		meterOptions = append(meterOptions, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(interval))))
+	}
+
+	mp := sdkmetric.NewMeterProvider(meterOptions...)
+
+	otel.SetMeterProvider(mp)
+
+	shutdowns := []func(context.Context) error{tp.Shutdown, mp.Shutdown}
+
+	// Logs only reach the backend when asked to, as they are also written to
+	// stdout.
+	if viper.GetBool("OTEL_LOGS_ENABLED") {
+		logExporter, err := exp.logExporter(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create log exporter: %w", err)
+		}
+
+		if logExporter != nil {
+			lp := sdklog.NewLoggerProvider(
+				sdklog.WithResource(res),
+				sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
+			)
+
+			global.SetLoggerProvider(lp)
+			shutdowns = append(shutdowns, lp.Shutdown)
+		}
+	}
+
+	// The connection to the collector is closed once every provider has
+	// flushed through it.
+	shutdowns = append(shutdowns, exp.Shutdown)
+	current.Store(exp)
+
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, shutdown := range shutdowns {
+			errs = append(errs, shutdown(ctx))
+		}
+		return errors.Join(errs...)
+	}, nil
+}
@@ -0,0 +1,102 @@
+package configs
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Version is the release the binary was built for, set at build time with
+// -ldflags "-X github.com/kameikay/shared/configs.Version=...".
+var Version string
+
+// newResource describes the service and where it runs: its name, version and
+// instance ID, the deployment environment, the host, the process and the
+// container, plus the attributes listed in OTEL_RESOURCE_ATTRIBUTES. The
+// service settings take precedence over OTEL_RESOURCE_ATTRIBUTES, which takes
+// precedence over what is detected.
+//
+// A detector that fails leaves its attributes out, and the error is returned
+// along with the rest of the resource, wrapping resource.ErrPartialResource.
+func newResource(ctx context.Context) (*resource.Resource, error) {
+	version := viper.GetString("SERVICE_VERSION")
+	if version == "" {
+		version = serviceVersion()
+	}
+	instanceID := viper.GetString("SERVICE_INSTANCE_ID")
+	if instanceID == "" {
+		instanceID = uuid.NewString()
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(viper.GetString("SERVICE_NAME")),
+		semconv.ServiceVersion(version),
+		semconv.ServiceInstanceID(instanceID),
+	}
+	if environment := viper.GetString("DEPLOYMENT_ENVIRONMENT"); environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(environment))
+	}
+
+	var extra []attribute.KeyValue
+	for key, value := range parsePairs(viper.GetString("OTEL_RESOURCE_ATTRIBUTES")) {
+		extra = append(extra, attribute.String(key, value))
+	}
+
+	// The command line is left out of the process attributes, as it may
+	// carry secrets.
+	return resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithOSType(),
+		resource.WithProcessPID(),
+		resource.WithProcessExecutableName(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithContainerID(),
+		resource.WithAttributes(extra...),
+		resource.WithAttributes(attrs...),
+	)
+}
+
+// serviceVersion returns Version when set, then the module version or the
+// VCS revision recorded by the Go toolchain, and "unknown" otherwise.
+func serviceVersion() string {
+	if Version != "" {
+		return Version
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "unknown"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return revision
+}
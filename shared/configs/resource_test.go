@@ -0,0 +1,40 @@
+package configs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestNewResource(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set("SERVICE_NAME", "service-orchestration")
+	viper.Set("SERVICE_VERSION", "1.4.0")
+	viper.Set("DEPLOYMENT_ENVIRONMENT", "staging")
+	viper.Set("OTEL_RESOURCE_ATTRIBUTES", "service.name=ignored,team=forecast,cloud.region=sa-east-1")
+
+	res, err := newResource(context.Background())
+	require.NoError(t, err)
+
+	attrs := map[attribute.Key]string{}
+	for _, attr := range res.Attributes() {
+		attrs[attr.Key] = attr.Value.Emit()
+	}
+
+	assert.Equal(t, "service-orchestration", attrs[semconv.ServiceNameKey])
+	assert.Equal(t, "1.4.0", attrs[semconv.ServiceVersionKey])
+	assert.NotEmpty(t, attrs[semconv.ServiceInstanceIDKey])
+	assert.Equal(t, "staging", attrs[semconv.DeploymentEnvironmentKey])
+	assert.Equal(t, "forecast", attrs["team"])
+	assert.Equal(t, "sa-east-1", attrs[semconv.CloudRegionKey])
+	assert.NotEmpty(t, attrs[semconv.HostNameKey])
+	assert.NotEmpty(t, attrs[semconv.ProcessPIDKey])
+	assert.NotContains(t, attrs, semconv.ProcessCommandArgsKey)
+	assert.Equal(t, semconv.SchemaURL, res.SchemaURL())
+}
@@ -0,0 +1,87 @@
+package configs
+
+import (
+	"strings"
+
+	"github.com/kameikay/shared/pkg/sampling"
+	"github.com/spf13/viper"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newSampler builds the head sampler from the TRACES_SAMPLER_* settings:
+// requests to the drop and keep routes are never and always sampled, other
+// traces are sampled at the configured ratio, up to the rate limit, and the
+// decision of the caller is honored. The returned flag tells whether the
+// unsampled spans are recorded so that the traces in which one ends in error
+// can still be exported. They are also recorded when tail sampling is enabled,
+// for it to pick the failed and slow traces among them.
+func newSampler() (sdktrace.Sampler, bool) {
+	viper.SetDefault("TRACES_SAMPLER_RATIO", 1.0)
+	viper.SetDefault("TRACES_SAMPLER_DROP_ROUTES", "/healthz,/readyz")
+	viper.SetDefault("TRACES_SAMPLER_KEEP_ERRORS", true)
+
+	var root sdktrace.Sampler = sdktrace.TraceIDRatioBased(viper.GetFloat64("TRACES_SAMPLER_RATIO"))
+	if rateLimit := viper.GetFloat64("TRACES_SAMPLER_RATE_LIMIT"); rateLimit > 0 {
+		root = sampling.RateLimited(rateLimit, root)
+	}
+
+	notSampled := sdktrace.NeverSample()
+	keepErrors := viper.GetBool("TRACES_SAMPLER_KEEP_ERRORS")
+	if keepErrors || viper.GetBool("TRACES_TAIL_SAMPLING_ENABLED") {
+		root = sampling.RecordDropped(root)
+		notSampled = sampling.RecordDropped(notSampled)
+	}
+
+	var rules []sampling.Rule
+	for _, path := range splitList(viper.GetString("TRACES_SAMPLER_DROP_ROUTES")) {
+		rules = append(rules, sampling.Rule{Path: path})
+	}
+	for _, path := range splitList(viper.GetString("TRACES_SAMPLER_KEEP_ROUTES")) {
+		rules = append(rules, sampling.Rule{Path: path, Sample: true})
+	}
+	if len(rules) > 0 {
+		root = sampling.RouteRules(rules, root)
+	}
+
+	return sdktrace.ParentBased(root,
+		sdktrace.WithRemoteParentNotSampled(notSampled),
+		sdktrace.WithLocalParentNotSampled(notSampled),
+	), keepErrors
+}
+
+// newSpanProcessor wraps the exporting processor with the tail sampler when
+// TRACES_TAIL_SAMPLING_ENABLED is set, and with the error processor when the
+// head sampler records the spans it drops.
+func newSpanProcessor(exporting sdktrace.SpanProcessor, keepErrors bool) sdktrace.SpanProcessor {
+	if viper.GetBool("TRACES_TAIL_SAMPLING_ENABLED") {
+		defaults := sampling.DefaultTailOptions()
+		viper.SetDefault("TRACES_TAIL_SAMPLING_LATENCY_THRESHOLD", defaults.LatencyThreshold)
+		viper.SetDefault("TRACES_TAIL_SAMPLING_PERCENTAGE", defaults.Percentage)
+		viper.SetDefault("TRACES_TAIL_SAMPLING_MAX_TRACES", defaults.MaxTraces)
+		viper.SetDefault("TRACES_TAIL_SAMPLING_MAX_SPANS_PER_TRACE", defaults.MaxSpansPerTrace)
+		viper.SetDefault("TRACES_TAIL_SAMPLING_DECISION_WAIT", defaults.DecisionWait)
+
+		return sampling.NewTailProcessor(exporting, sampling.TailOptions{
+			LatencyThreshold: viper.GetDuration("TRACES_TAIL_SAMPLING_LATENCY_THRESHOLD"),
+			Percentage:       viper.GetFloat64("TRACES_TAIL_SAMPLING_PERCENTAGE"),
+			MaxTraces:        viper.GetInt("TRACES_TAIL_SAMPLING_MAX_TRACES"),
+			MaxSpansPerTrace: viper.GetInt("TRACES_TAIL_SAMPLING_MAX_SPANS_PER_TRACE"),
+			DecisionWait:     viper.GetDuration("TRACES_TAIL_SAMPLING_DECISION_WAIT"),
+		})
+	}
+
+	if keepErrors {
+		return sampling.NewErrorProcessor(exporting)
+	}
+	return exporting
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
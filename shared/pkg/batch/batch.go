@@ -0,0 +1,191 @@
+// Package batch runs a lookup for every item of a list, for the endpoints
+// that accept many CEPs at once.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/telemetry"
+	"github.com/kameikay/shared/pkg/utils"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultMaxItems is the default of BATCH_MAX_ITEMS. service-input forwards
+	// the batches it accepts to service-orchestration whole, so both services
+	// must share the value.
+	DefaultMaxItems    = 100
+	DefaultConcurrency = 8
+)
+
+// Request is the body of the batch endpoints.
+type Request struct {
+	Ceps []string `json:"ceps"`
+}
+
+// Item is the outcome of the lookup of one CEP of a batch, holding either its
+// value or its error.
+type Item[T any] struct {
+	Cep   string            `json:"cep"`
+	Data  *T                `json:"data,omitempty"`
+	Error *exceptions.Error `json:"error,omitempty"`
+}
+
+// Result is the outcome of the lookup of one item.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Serve answers r, a batch request traced by span, with one item per CEP it
+// holds, in the order they were given. lookup returns the result of every CEP
+// and key tells which CEPs are the same one, as Run does. The request only
+// fails as a whole when it is malformed, empty or holds more than
+// BATCH_MAX_ITEMS CEPs.
+func Serve[T any](w http.ResponseWriter, r *http.Request, span trace.Span, key func(cep string) string, lookup func(ceps []string) []Result[T]) {
+	var input Request
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		telemetry.ValidationFailed(span, "body", err)
+		utils.ErrorResponse(w, r, exceptions.Wrap(exceptions.ErrInvalidRequest, err))
+		return
+	}
+
+	viper.SetDefault("BATCH_MAX_ITEMS", DefaultMaxItems)
+	err = Validate(input.Ceps, viper.GetInt("BATCH_MAX_ITEMS"))
+	if err != nil {
+		telemetry.ValidationFailed(span, "ceps", err)
+		utils.ErrorResponse(w, r, err)
+		return
+	}
+
+	span.SetAttributes(telemetry.Batch(len(input.Ceps), Distinct(input.Ceps, key))...)
+
+	items := Items(input.Ceps, lookup(input.Ceps))
+	failed := 0
+	for _, item := range items {
+		if item.Error != nil {
+			failed++
+		}
+	}
+	span.SetAttributes(telemetry.BatchFailedKey.Int(failed))
+
+	utils.JsonResponse(w, utils.ResponseDTO{
+		StatusCode: http.StatusOK,
+		Message:    http.StatusText(http.StatusOK),
+		Success:    true,
+		Data:       items,
+	})
+}
+
+// Items pairs every CEP with its result.
+func Items[T any](ceps []string, results []Result[T]) []Item[T] {
+	items := make([]Item[T], len(ceps))
+	for i, result := range results {
+		items[i] = Item[T]{Cep: ceps[i]}
+		if result.Err != nil {
+			items[i].Error = exceptions.From(result.Err)
+			continue
+		}
+		value := result.Value
+		items[i].Data = &value
+	}
+	return items
+}
+
+// Run calls fn for every distinct item, with at most concurrency calls in
+// flight, and returns the results in the order of items. Items with the same
+// key, the item itself when key is nil, share the result of a single call,
+// made with the first of them. Items not started by the time ctx is done fail
+// with its error.
+func Run[T any](ctx context.Context, items []string, key func(item string) string, concurrency int, fn func(ctx context.Context, item string) (T, error)) []Result[T] {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	if key == nil {
+		key = identity
+	}
+
+	positions := make(map[string]int, len(items))
+	var distinct []string
+	for _, item := range items {
+		if _, ok := positions[key(item)]; !ok {
+			positions[key(item)] = len(distinct)
+			distinct = append(distinct, item)
+		}
+	}
+
+	outcomes := make([]Result[T], len(distinct))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, item := range distinct {
+		select {
+		case slots <- struct{}{}:
+			// A slot may have been freed as ctx got done, in which case
+			// either case could have been chosen.
+			if err := ctx.Err(); err != nil {
+				<-slots
+				outcomes[i].Err = err
+				continue
+			}
+		case <-ctx.Done():
+			outcomes[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			outcomes[i].Value, outcomes[i].Err = fn(ctx, item)
+		}()
+	}
+	wg.Wait()
+
+	results := make([]Result[T], len(items))
+	for i, item := range items {
+		results[i] = outcomes[positions[key(item)]]
+	}
+	return results
+}
+
+// Distinct returns how many different items there are, told apart by key as
+// Run does.
+func Distinct(items []string, key func(item string) string) int {
+	if key == nil {
+		key = identity
+	}
+
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		seen[key(item)] = struct{}{}
+	}
+	return len(seen)
+}
+
+func identity(item string) string {
+	return item
+}
+
+// Validate rejects empty batches and those holding more than maxItems items.
+func Validate(items []string, maxItems int) error {
+	if maxItems <= 0 {
+		maxItems = DefaultMaxItems
+	}
+
+	switch {
+	case len(items) == 0:
+		return exceptions.Wrap(exceptions.ErrInvalidRequest, errors.New("no ceps given"))
+	case len(items) > maxItems:
+		return exceptions.Wrap(exceptions.ErrInvalidRequest, fmt.Errorf("%d ceps given, at most %d are accepted", len(items), maxItems))
+	}
+
+	return nil
+}
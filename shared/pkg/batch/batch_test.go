@@ -0,0 +1,103 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunKeepsOrderAndDeduplicates(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+
+	results := Run(context.Background(), []string{"b", "a", "b", "c", "a"}, nil, 2, func(_ context.Context, item string) (string, error) {
+		mu.Lock()
+		calls[item]++
+		mu.Unlock()
+
+		if item == "c" {
+			return "", errors.New("not found")
+		}
+		return strings.ToUpper(item), nil
+	})
+
+	require.Len(t, results, 5)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1}, calls)
+	assert.Equal(t, Result[string]{Value: "B"}, results[0])
+	assert.Equal(t, Result[string]{Value: "A"}, results[1])
+	assert.Equal(t, Result[string]{Value: "B"}, results[2])
+	assert.EqualError(t, results[3].Err, "not found")
+	assert.Equal(t, Result[string]{Value: "A"}, results[4])
+}
+
+func TestRunBoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+
+	Run(context.Background(), []string{"a", "b", "c", "d", "e", "f"}, nil, 2, func(context.Context, string) (struct{}, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			previous := peak.Load()
+			if current <= previous || peak.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return struct{}{}, nil
+	})
+
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestRunFailsItemsNotStartedWhenDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	results := Run(ctx, []string{"a", "b", "c"}, nil, 1, func(context.Context, string) (int, error) {
+		cancel()
+		return 1, nil
+	})
+
+	assert.Equal(t, Result[int]{Value: 1}, results[0])
+	assert.ErrorIs(t, results[1].Err, context.Canceled)
+	assert.ErrorIs(t, results[2].Err, context.Canceled)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]string{"a", "b"}, 2))
+	assert.ErrorIs(t, Validate(nil, 2), exceptions.ErrInvalidRequest)
+	assert.EqualError(t, Validate([]string{"a", "b", "c"}, 2), "invalid request: 3 ceps given, at most 2 are accepted")
+	assert.Equal(t, 2, Distinct([]string{"a", "b", "a"}, nil))
+	assert.Equal(t, 1, Distinct([]string{"a", "A"}, strings.ToLower))
+}
+
+func TestRunDeduplicatesByKey(t *testing.T) {
+	var calls []string
+
+	results := Run(context.Background(), []string{"01001-000", "01001000", "20040020"}, func(item string) string {
+		return strings.ReplaceAll(item, "-", "")
+	}, 1, func(_ context.Context, item string) (string, error) {
+		calls = append(calls, item)
+		return item, nil
+	})
+
+	assert.Equal(t, []string{"01001-000", "20040020"}, calls)
+	assert.Equal(t, []Result[string]{{Value: "01001-000"}, {Value: "01001-000"}, {Value: "20040020"}}, results)
+}
+
+func TestItems(t *testing.T) {
+	items := Items([]string{"01001000", "99999999"}, []Result[int]{{Value: 20}, {Err: exceptions.ErrCannotFindZipcode}})
+
+	value := 20
+	assert.Equal(t, []Item[int]{
+		{Cep: "01001000", Data: &value},
+		{Cep: "99999999", Error: exceptions.ErrCannotFindZipcode},
+	}, items)
+}
@@ -0,0 +1,203 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultFailureThreshold = 5
+	DefaultCooldown         = 30 * time.Second
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError is returned instead of calling a dependency whose breaker is
+// open. It matches ErrOpen with errors.Is.
+type OpenError struct {
+	Name string
+}
+
+func (e *OpenError) Error() string {
+	return ErrOpen.Error() + ": " + e.Name
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type Options struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker.
+	FailureThreshold int
+	// Cooldown is how long the breaker stays open before letting a trial
+	// call through.
+	Cooldown time.Duration
+}
+
+type Snapshot struct {
+	Name             string     `json:"name"`
+	State            State      `json:"state"`
+	Failures         int        `json:"failures"`
+	FailureThreshold int        `json:"failure_threshold"`
+	OpenedAt         *time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker isolates a dependency: after FailureThreshold consecutive failures
+// it opens and fails fast for Cooldown, then half-opens and lets a single
+// trial call decide whether to close again.
+type Breaker struct {
+	name    string
+	options Options
+	now     func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func New(name string, options Options) *Breaker {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = DefaultFailureThreshold
+	}
+	if options.Cooldown <= 0 {
+		options.Cooldown = DefaultCooldown
+	}
+
+	return &Breaker{
+		name:    name,
+		options: options,
+		now:     time.Now,
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reports whether a call may go through, returning an *OpenError when
+// it must fail fast. Every allowed call must be followed by Record or
+// Release.
+func (b *Breaker) Allow(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.options.Cooldown {
+		b.transition(ctx, StateHalfOpen)
+	}
+
+	switch b.state {
+	case StateOpen:
+		return &OpenError{Name: b.name}
+	case StateHalfOpen:
+		if b.trial {
+			return &OpenError{Name: b.name}
+		}
+		b.trial = true
+	}
+
+	return nil
+}
+
+// Record reports the outcome of a call allowed by Allow.
+func (b *Breaker) Record(ctx context.Context, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.trial = false
+		if success {
+			b.failures = 0
+			b.transition(ctx, StateClosed)
+		} else {
+			b.open(ctx)
+		}
+		return
+	}
+
+	if success {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == StateClosed && b.failures >= b.options.FailureThreshold {
+		b.open(ctx)
+	}
+}
+
+// Release gives back an allowed call without an outcome, as when the caller
+// cancelled it.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.trial = false
+	}
+}
+
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := Snapshot{
+		Name:             b.name,
+		State:            b.state,
+		Failures:         b.failures,
+		FailureThreshold: b.options.FailureThreshold,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	return snapshot
+}
+
+func (b *Breaker) open(ctx context.Context) {
+	b.openedAt = b.now()
+	b.transition(ctx, StateOpen)
+}
+
+// transition changes state and records it as an event on the span of the
+// call that caused it.
+func (b *Breaker) transition(ctx context.Context, to State) {
+	from := b.state
+	b.state = to
+
+	trace.SpanFromContext(ctx).AddEvent("circuit_breaker.state_change", trace.WithAttributes(
+		attribute.String("circuit_breaker.name", b.name),
+		attribute.String("circuit_breaker.from", from.String()),
+		attribute.String("circuit_breaker.to", to.String()),
+		attribute.Int("circuit_breaker.failures", b.failures),
+	))
+}
@@ -0,0 +1,132 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	ctx := context.Background()
+	b := New("test", Options{FailureThreshold: 2, Cooldown: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, b.Allow(ctx))
+		b.Record(ctx, false)
+	}
+
+	err := b.Allow(ctx)
+	assert.True(t, errors.Is(err, ErrOpen))
+	assert.Equal(t, "circuit breaker is open: test", err.Error())
+	assert.Equal(t, StateOpen, b.Snapshot().State)
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	ctx := context.Background()
+	b := New("test", Options{FailureThreshold: 2, Cooldown: time.Minute})
+
+	b.Allow(ctx)
+	b.Record(ctx, false)
+	b.Allow(ctx)
+	b.Record(ctx, true)
+	b.Allow(ctx)
+	b.Record(ctx, false)
+
+	assert.NoError(t, b.Allow(ctx))
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	b := New("test", Options{FailureThreshold: 1, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	b.Allow(ctx)
+	b.Record(ctx, false)
+	assert.Error(t, b.Allow(ctx))
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow(ctx))
+	assert.Equal(t, StateHalfOpen, b.Snapshot().State)
+	assert.Error(t, b.Allow(ctx), "only one trial call is let through")
+
+	b.Record(ctx, false)
+	assert.Equal(t, StateOpen, b.Snapshot().State)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow(ctx))
+	b.Record(ctx, true)
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+	assert.NoError(t, b.Allow(ctx))
+}
+
+func TestBreakerRecordsTransitionsAsSpanEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "call")
+
+	b := New("test", Options{FailureThreshold: 1, Cooldown: time.Minute})
+	b.Allow(ctx)
+	b.Record(ctx, false)
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "circuit_breaker.state_change", events[0].Name)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	b := New("test", Options{FailureThreshold: 2, Cooldown: time.Minute})
+	client := &http.Client{Transport: NewTransport(b, nil)}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		res.Body.Close()
+	}
+
+	_, err := client.Get(server.URL)
+	assert.True(t, errors.Is(err, ErrOpen))
+}
+
+func TestTransportIgnoresClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	b := New("test", Options{FailureThreshold: 1, Cooldown: time.Minute})
+	client := &http.Client{Transport: NewTransport(b, nil)}
+
+	for i := 0; i < 3; i++ {
+		res, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		res.Body.Close()
+	}
+	assert.Equal(t, StateClosed, b.Snapshot().State)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	b := registry.Get("b", Options{})
+	assert.Same(t, b, registry.Get("b", Options{}))
+	registry.Get("a", Options{})
+
+	snapshots := registry.Snapshots()
+	assert.Equal(t, "a", snapshots[0].Name)
+	assert.Equal(t, "b", snapshots[1].Name)
+}
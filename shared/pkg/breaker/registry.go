@@ -0,0 +1,52 @@
+package breaker
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registry keeps one breaker per dependency name so that every client of the
+// same dependency shares its state, and so the states can be reported.
+type Registry struct {
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// DefaultRegistry is the registry used by the services' HTTP clients.
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{breakers: make(map[string]*Breaker)}
+}
+
+// Get returns the breaker registered under name, creating it with options
+// on first use.
+func (r *Registry) Get(name string, options Options) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, ok := r.breakers[name]; ok {
+		return b
+	}
+
+	b := New(name, options)
+	r.breakers[name] = b
+	return b
+}
+
+// Snapshots returns the current state of every breaker, sorted by name.
+func (r *Registry) Snapshots() []Snapshot {
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.Unlock()
+
+	snapshots := make([]Snapshot, 0, len(breakers))
+	for _, b := range breakers {
+		snapshots = append(snapshots, b.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots
+}
@@ -0,0 +1,46 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Transport guards an http.RoundTripper with a breaker. Transport errors and
+// 5xx responses count as failures; a request cancelled by its caller does
+// not count either way.
+type Transport struct {
+	breaker *Breaker
+	next    http.RoundTripper
+}
+
+func NewTransport(breaker *Breaker, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Transport{
+		breaker: breaker,
+		next:    next,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if err := t.breaker.Allow(ctx); err != nil {
+		return nil, err
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			t.breaker.Release()
+			return nil, err
+		}
+		t.breaker.Record(ctx, false)
+		return nil, err
+	}
+
+	t.breaker.Record(ctx, res.StatusCode < http.StatusInternalServerError)
+	return res, nil
+}
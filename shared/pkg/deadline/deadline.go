@@ -0,0 +1,82 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header carries, in milliseconds, the time a service has left to answer the
+// request it received, so the next hop can work within the same budget.
+const Header = "X-Request-Budget-Ms"
+
+// Middleware sets the deadline of each request to the budget received in
+// Header, capped by timeout. Without a budget the request gets timeout, and
+// with neither it has no deadline.
+func Middleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			budget, ok := parse(r.Header.Get(Header))
+			if !ok || (timeout > 0 && budget > timeout) {
+				budget = timeout
+			}
+			if !ok && budget <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Remaining returns the time left before the deadline of ctx, if it has one.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return max(time.Until(deadline), 0), true
+}
+
+// Inject forwards the remaining budget of ctx in header.
+func Inject(ctx context.Context, header http.Header) {
+	if remaining, ok := Remaining(ctx); ok {
+		header.Set(Header, strconv.FormatInt(remaining.Milliseconds(), 10))
+	}
+}
+
+// Share derives a context that may use only fraction of the remaining budget
+// of ctx, leaving the rest for the steps that follow. A ctx without deadline
+// is returned as is.
+func Share(ctx context.Context, fraction float64) (context.Context, context.CancelFunc) {
+	remaining, ok := Remaining(ctx)
+	if !ok || fraction <= 0 || fraction >= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(fraction*float64(remaining)))
+}
+
+// Annotate records the remaining budget of ctx on span.
+func Annotate(ctx context.Context, span trace.Span) {
+	if remaining, ok := Remaining(ctx); ok {
+		span.SetAttributes(attribute.Int64("deadline.remaining_ms", remaining.Milliseconds()))
+	}
+}
+
+func parse(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms < 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
@@ -0,0 +1,79 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(t *testing.T, timeout time.Duration, budget string) (time.Duration, bool) {
+	var remaining time.Duration
+	var ok bool
+	handler := Middleware(timeout)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining, ok = Remaining(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if budget != "" {
+		req.Header.Set(Header, budget)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	return remaining, ok
+}
+
+func TestMiddleware(t *testing.T) {
+	remaining, ok := serve(t, 0, "")
+	assert.False(t, ok)
+
+	remaining, ok = serve(t, 5*time.Second, "")
+	assert.True(t, ok)
+	assert.InDelta(t, 5*time.Second, remaining, float64(100*time.Millisecond))
+
+	remaining, ok = serve(t, 5*time.Second, "2000")
+	assert.True(t, ok)
+	assert.InDelta(t, 2*time.Second, remaining, float64(100*time.Millisecond))
+
+	remaining, ok = serve(t, time.Second, "2000")
+	assert.True(t, ok)
+	assert.InDelta(t, time.Second, remaining, float64(100*time.Millisecond))
+
+	remaining, ok = serve(t, 0, "0")
+	assert.True(t, ok)
+	assert.Zero(t, remaining)
+
+	remaining, ok = serve(t, time.Second, "garbage")
+	assert.True(t, ok)
+	assert.InDelta(t, time.Second, remaining, float64(100*time.Millisecond))
+}
+
+func TestInject(t *testing.T) {
+	header := http.Header{}
+	Inject(context.Background(), header)
+	assert.Empty(t, header.Get(Header))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	Inject(ctx, header)
+	budget, ok := parse(header.Get(Header))
+	assert.True(t, ok)
+	assert.InDelta(t, 3*time.Second, budget, float64(100*time.Millisecond))
+}
+
+func TestShare(t *testing.T) {
+	ctx, cancel := Share(context.Background(), 0.5)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	parent, cancelParent := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancelParent()
+	ctx, cancel = Share(parent, 0.25)
+	defer cancel()
+	remaining, ok := Remaining(ctx)
+	assert.True(t, ok)
+	assert.InDelta(t, time.Second, remaining, float64(100*time.Millisecond))
+}
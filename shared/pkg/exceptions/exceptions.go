@@ -0,0 +1,153 @@
+package exceptions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/kameikay/shared/pkg/breaker"
+)
+
+// Code identifies a kind of failure. Codes are part of the API: they travel
+// from service-orchestration to service-input and on to the clients, so they
+// must not change once published.
+type Code string
+
+const (
+	CodeInvalidRequest            Code = "INVALID_REQUEST"
+	CodeInvalidCEP                Code = "INVALID_CEP"
+	CodeZipcodeNotFound           Code = "ZIPCODE_NOT_FOUND"
+	CodeWeatherDataNotFound       Code = "WEATHER_DATA_NOT_FOUND"
+	CodeCEPServiceUnavailable     Code = "CEP_SERVICE_UNAVAILABLE"
+	CodeWeatherServiceUnavailable Code = "WEATHER_SERVICE_UNAVAILABLE"
+	CodeCircuitOpen               Code = "CIRCUIT_OPEN"
+	CodeDeadlineExceeded          Code = "DEADLINE_EXCEEDED"
+	CodeUpstreamError             Code = "UPSTREAM_ERROR"
+)
+
+var (
+	ErrInvalidRequest            = New(CodeInvalidRequest, http.StatusBadRequest, false, "invalid request")
+	ErrInvalidCEP                = New(CodeInvalidCEP, http.StatusUnprocessableEntity, false, "invalid zipcode")
+	ErrCannotFindZipcode         = New(CodeZipcodeNotFound, http.StatusNotFound, false, "can not find zipcode")
+	ErrCannotFindWeatherData     = New(CodeWeatherDataNotFound, http.StatusNotFound, false, "cannot find weather data")
+	ErrCEPServiceUnavailable     = New(CodeCEPServiceUnavailable, http.StatusServiceUnavailable, true, "zipcode service unavailable")
+	ErrWeatherServiceUnavailable = New(CodeWeatherServiceUnavailable, http.StatusBadGateway, true, "weather service unavailable")
+	ErrCircuitOpen               = New(CodeCircuitOpen, http.StatusServiceUnavailable, true, "dependency unavailable")
+	ErrDeadlineExceeded          = New(CodeDeadlineExceeded, http.StatusGatewayTimeout, true, "request deadline exceeded")
+	ErrUpstream                  = New(CodeUpstreamError, http.StatusBadGateway, true, "upstream service failed")
+)
+
+// Error is a failure the services know how to answer with. Errors match the
+// errors of the same code with errors.Is, including those rebuilt from the
+// JSON sent by another service.
+type Error struct {
+	Code Code
+	// Status is the HTTP status the error is answered with.
+	Status int
+	// Retryable tells whether the same request may succeed later.
+	Retryable bool
+	Message   string
+	// Cause is the underlying error. It is not sent over the wire.
+	Cause error
+}
+
+func New(code Code, status int, retryable bool, message string) *Error {
+	return &Error{
+		Code:      code,
+		Status:    status,
+		Retryable: retryable,
+		Message:   message,
+	}
+}
+
+// Wrap returns a copy of err caused by cause.
+func Wrap(err *Error, cause error) *Error {
+	wrapped := *err
+	wrapped.Cause = cause
+	return &wrapped
+}
+
+func (e *Error) Error() string {
+	if e.Cause == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Cause.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// wireError is how an Error is sent to other services and clients.
+type wireError struct {
+	Code      Code   `json:"code"`
+	Status    int    `json:"status"`
+	Retryable bool   `json:"retryable"`
+	Message   string `json:"message"`
+}
+
+// MarshalJSON leaves the cause out: it is meant for logs and spans, and may
+// describe the internals of an upstream call.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wireError{
+		Code:      e.Code,
+		Status:    e.Status,
+		Retryable: e.Retryable,
+		Message:   e.Message,
+	})
+}
+
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var wire wireError
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+
+	*e = Error{
+		Code:      wire.Code,
+		Status:    wire.Status,
+		Retryable: wire.Retryable,
+		Message:   wire.Message,
+	}
+	return nil
+}
+
+// From classifies err. Errors that are neither an Error, an open circuit nor
+// an exhausted deadline come from a failed upstream call, which is all these
+// services do besides validating their input.
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, breaker.ErrOpen):
+		return Wrap(ErrCircuitOpen, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(ErrDeadlineExceeded, err)
+	default:
+		return Wrap(ErrUpstream, err)
+	}
+}
+
+// FromStatus rebuilds the error answered by a service that did not describe
+// it, from the status of its response.
+func FromStatus(status int, message string) *Error {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	switch status {
+	case http.StatusGatewayTimeout:
+		return Wrap(ErrDeadlineExceeded, errors.New(message))
+	case http.StatusServiceUnavailable:
+		return New(CodeUpstreamError, http.StatusServiceUnavailable, true, message)
+	}
+
+	return Wrap(ErrUpstream, errors.New(message))
+}
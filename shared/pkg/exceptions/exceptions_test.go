@@ -0,0 +1,79 @@
+package exceptions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorMatchesByCode(t *testing.T) {
+	err := fmt.Errorf("lookup: %w", Wrap(ErrCEPServiceUnavailable, errors.New("viacep responded with status 500")))
+
+	assert.ErrorIs(t, err, ErrCEPServiceUnavailable)
+	assert.NotErrorIs(t, err, ErrUpstream)
+	assert.EqualError(t, err, "lookup: zipcode service unavailable: viacep responded with status 500")
+	assert.Nil(t, ErrCEPServiceUnavailable.Cause, "wrapping must not change the sentinel")
+}
+
+func TestErrorRoundTrip(t *testing.T) {
+	sent := Wrap(ErrWeatherServiceUnavailable, errors.New("weatherapi responded with status 500"))
+
+	data, err := json.Marshal(sent)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"code": "WEATHER_SERVICE_UNAVAILABLE",
+		"status": 502,
+		"retryable": true,
+		"message": "weather service unavailable"
+	}`, string(data))
+
+	var received *Error
+	require.NoError(t, json.Unmarshal(data, &received))
+	assert.ErrorIs(t, received, ErrWeatherServiceUnavailable)
+	assert.Equal(t, sent.Status, received.Status)
+	assert.Equal(t, sent.Retryable, received.Retryable)
+	assert.Equal(t, sent.Message, received.Error())
+	assert.Nil(t, received.Cause)
+}
+
+func TestFrom(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected *Error
+		status   int
+	}{
+		{name: "error", err: fmt.Errorf("lookup: %w", ErrCannotFindZipcode), expected: ErrCannotFindZipcode, status: http.StatusNotFound},
+		{name: "open circuit", err: &breaker.OpenError{Name: "viacep"}, expected: ErrCircuitOpen, status: http.StatusServiceUnavailable},
+		{name: "deadline", err: fmt.Errorf("get: %w", context.DeadlineExceeded), expected: ErrDeadlineExceeded, status: http.StatusGatewayTimeout},
+		{name: "anything else", err: errors.New("connection refused"), expected: ErrUpstream, status: http.StatusBadGateway},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := From(tc.err)
+			assert.ErrorIs(t, e, tc.expected)
+			assert.Equal(t, tc.status, e.Status)
+		})
+	}
+
+	assert.EqualError(t, From(&breaker.OpenError{Name: "viacep"}), "dependency unavailable: circuit breaker is open: viacep")
+}
+
+func TestFromStatus(t *testing.T) {
+	assert.ErrorIs(t, FromStatus(http.StatusGatewayTimeout, ""), ErrDeadlineExceeded)
+
+	unavailable := FromStatus(http.StatusServiceUnavailable, "")
+	assert.ErrorIs(t, unavailable, ErrUpstream)
+	assert.Equal(t, http.StatusServiceUnavailable, unavailable.Status)
+	assert.EqualError(t, unavailable, "Service Unavailable")
+
+	assert.EqualError(t, FromStatus(http.StatusNotFound, "not found"), "upstream service failed: not found")
+}
@@ -0,0 +1,173 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTTL     = 30 * time.Second
+	DefaultTimeout = 2 * time.Second
+)
+
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Check returns nil when the component it checks is healthy.
+type Check func(ctx context.Context) error
+
+type Result struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the outcome of every check of a Checker. Its status is up only
+// when every check is.
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker runs a set of named checks concurrently. A result is reused for TTL
+// after it was obtained, so that probing an upstream API on every request
+// does not hammer it, and concurrent runs wait for the check in flight
+// instead of starting their own.
+type Checker struct {
+	// TTL is how long a result is reused. Zero runs the checks every time.
+	TTL time.Duration
+	// Timeout bounds every check. Zero leaves them bounded by the context
+	// given to Run only.
+	Timeout time.Duration
+
+	now    func() time.Time
+	mu     sync.Mutex
+	checks []*cachedCheck
+}
+
+type cachedCheck struct {
+	name  string
+	check Check
+
+	mu     sync.Mutex
+	result *Result
+}
+
+func NewChecker(ttl, timeout time.Duration) *Checker {
+	return &Checker{
+		TTL:     ttl,
+		Timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// Add registers check under name.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, &cachedCheck{name: name, check: check})
+}
+
+// Run returns the result of every check, sorted by name.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append([]*cachedCheck(nil), c.checks...)
+	c.mu.Unlock()
+
+	report := Report{Status: StatusUp, Checks: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check *cachedCheck) Result {
+	check.mu.Lock()
+	defer check.mu.Unlock()
+
+	if check.result != nil && c.now().Sub(check.result.CheckedAt) < c.TTL {
+		return *check.result
+	}
+
+	// The result is shared with other callers, so it must not be cut short
+	// because the caller that triggered it went away.
+	ctx = context.WithoutCancel(ctx)
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	start := c.now()
+	err := check.check(ctx)
+	result := Result{
+		Name:      check.name,
+		Status:    StatusUp,
+		LatencyMs: c.now().Sub(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	check.result = &result
+	return result
+}
+
+// HTTPCheck returns a check that sends a GET request to rawURL and expects a
+// 2xx answer. Errors do not include the URL, which may carry credentials.
+func HTTPCheck(client *http.Client, rawURL string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return errors.New("invalid probe url")
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				return urlErr.Err
+			}
+			return err
+		}
+		defer res.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("responded with status %d", res.StatusCode)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckerReportsEveryCheck(t *testing.T) {
+	checker := NewChecker(0, time.Second)
+	checker.Add("weatherapi", func(context.Context) error { return errors.New("unreachable") })
+	checker.Add("viacep", func(context.Context) error { return nil })
+
+	report := checker.Run(context.Background())
+
+	assert.Equal(t, StatusDown, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "viacep", report.Checks[0].Name)
+	assert.Equal(t, StatusUp, report.Checks[0].Status)
+	assert.Empty(t, report.Checks[0].Error)
+	assert.Equal(t, "weatherapi", report.Checks[1].Name)
+	assert.Equal(t, StatusDown, report.Checks[1].Status)
+	assert.Equal(t, "unreachable", report.Checks[1].Error)
+}
+
+func TestCheckerWithoutChecksIsUp(t *testing.T) {
+	assert.Equal(t, StatusUp, NewChecker(0, 0).Run(context.Background()).Status)
+}
+
+func TestCheckerCachesResults(t *testing.T) {
+	now := time.Now()
+	checker := NewChecker(time.Minute, time.Second)
+	checker.now = func() time.Time { return now }
+
+	var calls atomic.Int32
+	checker.Add("viacep", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	checker.Run(context.Background())
+	checker.Run(context.Background())
+	assert.Equal(t, int32(1), calls.Load())
+
+	now = now.Add(time.Minute)
+	checker.Run(context.Background())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCheckerTimesOutChecks(t *testing.T) {
+	checker := NewChecker(0, 10*time.Millisecond)
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Run(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestHTTPCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, HTTPCheck(server.Client(), server.URL+"?key=secret")(context.Background()))
+	assert.EqualError(t, HTTPCheck(server.Client(), server.URL+"?key=wrong")(context.Background()), "responded with status 401")
+
+	server.Close()
+	err := HTTPCheck(server.Client(), server.URL+"?key=secret")(context.Background())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
@@ -0,0 +1,128 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/metrics"
+	"github.com/kameikay/shared/pkg/retry"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	// defaultTimeout bounds a whole upstream call, retries included, for
+	// requests that carry no deadline of their own.
+	defaultTimeout = 10 * time.Second
+	// maxPeekedBody bounds how much of a failed answer is read to find out
+	// whether it is worth another attempt.
+	maxPeekedBody = 64 << 10
+)
+
+// NewHTTPClient returns the client used to reach dependency. Failed calls are
+// retried with backoff, and the circuit breaker registered under the
+// dependency's name sees only the outcome of the last attempt, so an open
+// breaker fails fast without any retry. Every attempt is measured on its own.
+func NewHTTPClient(dependency string) *http.Client {
+	circuitBreaker := breaker.DefaultRegistry.Get(dependency, breaker.Options{
+		FailureThreshold: viper.GetInt("CIRCUIT_BREAKER_FAILURE_THRESHOLD"),
+		Cooldown:         viper.GetDuration("CIRCUIT_BREAKER_COOLDOWN"),
+	})
+
+	timeout := viper.GetDuration("HTTP_CLIENT_TIMEOUT")
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: breaker.NewTransport(circuitBreaker, retry.NewTransport(retryPolicy(), metrics.NewTransport(dependency, newTracingTransport(dependency)))),
+	}
+}
+
+// newTracingTransport starts a client span for every attempt made to
+// dependency and propagates the trace context in its headers.
+func newTracingTransport(dependency string) http.RoundTripper {
+	return otelhttp.NewTransport(http.DefaultTransport,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + dependency
+		}),
+	)
+}
+
+// retryPolicy overrides retry.DefaultPolicy with the RETRY_* settings that
+// are present in the environment.
+func retryPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.RetryableResponse = retryableAnswer
+	if maxAttempts := viper.GetInt("RETRY_MAX_ATTEMPTS"); maxAttempts > 0 {
+		policy.MaxAttempts = maxAttempts
+	}
+	if baseDelay := viper.GetDuration("RETRY_BASE_DELAY"); baseDelay > 0 {
+		policy.BaseDelay = baseDelay
+	}
+	if maxDelay := viper.GetDuration("RETRY_MAX_DELAY"); maxDelay > 0 {
+		policy.MaxDelay = maxDelay
+	}
+	if viper.GetString("RETRY_JITTER") != "" {
+		policy.Jitter = viper.GetFloat64("RETRY_JITTER")
+	}
+	if statusCodes := retry.ParseStatusCodes(viper.GetString("RETRY_STATUS_CODES")); len(statusCodes) > 0 {
+		policy.RetryableStatusCodes = statusCodes
+	}
+	return policy
+}
+
+// retryableAnswer tells whether a failed answer is worth another attempt.
+// Answers of the other service describing a failure that is not retryable,
+// or an open circuit, are not: the other service already retried its own
+// dependencies. Other answers are left to their status.
+func retryableAnswer(res *http.Response) bool {
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxPeekedBody))
+	res.Body = peekedBody{Reader: io.MultiReader(bytes.NewReader(body), res.Body), Closer: res.Body}
+	if err != nil {
+		return true
+	}
+
+	var answer struct {
+		Error *exceptions.Error `json:"error"`
+	}
+	if json.Unmarshal(body, &answer) != nil || answer.Error == nil || answer.Error.Code == "" {
+		return true
+	}
+
+	return answer.Error.Retryable && answer.Error.Code != exceptions.CodeCircuitOpen
+}
+
+// peekedBody is a response body whose beginning was read and put back.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// Redact removes the query, where some providers take their API key, from
+// the URL carried by an error of http.Client.Do, so that the error can be
+// logged and recorded on spans.
+func Redact(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	redacted := *urlErr
+	redacted.URL = ""
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+		u.User = nil
+		u.RawQuery = ""
+		u.Fragment = ""
+		redacted.URL = u.String()
+	}
+	return &redacted
+}
@@ -0,0 +1,120 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/kameikay/shared/pkg/utils"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	defer viper.Reset()
+	viper.Set("RETRY_MAX_ATTEMPTS", 2)
+	viper.Set("RETRY_BASE_DELAY", time.Millisecond)
+	viper.Set("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 1)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient("httpclient-test")
+	res, err := client.Get(server.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, int32(2), calls.Load(), "the failed call is retried")
+
+	_, err = client.Get(server.URL)
+	assert.ErrorIs(t, err, breaker.ErrOpen, "the breaker sees the outcome of the last attempt")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestNewHTTPClientRetries(t *testing.T) {
+	defer viper.Reset()
+	viper.Set("RETRY_MAX_ATTEMPTS", 3)
+	viper.Set("RETRY_BASE_DELAY", time.Millisecond)
+	viper.Set("RETRY_STATUS_CODES", "500, 503,nope")
+	viper.Set("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 100)
+
+	testCases := []struct {
+		name     string
+		answer   func(w http.ResponseWriter, r *http.Request)
+		attempts int32
+	}{
+		{
+			name:     "configured status",
+			answer:   func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			attempts: 3,
+		},
+		{
+			name:     "status no longer configured",
+			answer:   func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+			attempts: 1,
+		},
+		{
+			name: "retryable error",
+			answer: func(w http.ResponseWriter, r *http.Request) {
+				utils.ErrorResponse(w, r, exceptions.ErrCEPServiceUnavailable)
+			},
+			attempts: 3,
+		},
+		{
+			name: "open circuit",
+			answer: func(w http.ResponseWriter, r *http.Request) {
+				utils.ErrorResponse(w, r, &breaker.OpenError{Name: "viacep"})
+			},
+			attempts: 1,
+		},
+		{
+			name: "error that is not retryable",
+			answer: func(w http.ResponseWriter, r *http.Request) {
+				utils.ErrorResponse(w, r, exceptions.New(exceptions.CodeUpstreamError, http.StatusServiceUnavailable, false, "quota exhausted"))
+			},
+			attempts: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				tc.answer(w, r)
+			}))
+			defer server.Close()
+
+			res, err := NewHTTPClient("httpclient-" + tc.name).Get(server.URL)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.attempts, calls.Load())
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	_, err := http.Get(server.URL + "/v1/current.json?key=secret&q=Paris")
+	require.ErrorContains(t, err, "secret")
+
+	redacted := Redact(err)
+	assert.NotContains(t, redacted.Error(), "secret")
+	assert.Contains(t, redacted.Error(), server.URL+"/v1/current.json")
+	assert.NotContains(t, exceptions.Wrap(exceptions.ErrWeatherServiceUnavailable, redacted).Error(), "secret")
+	assert.Equal(t, errors.Unwrap(err), errors.Unwrap(redacted), "only the URL is changed")
+}
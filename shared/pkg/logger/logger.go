@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options configures the logger returned by New.
+type Options struct {
+	// Format is either "json", the default, or "text".
+	Format string
+	Level  slog.Level
+	// Writer defaults to os.Stdout.
+	Writer io.Writer
+	// OTel also sends every record through the OpenTelemetry logs bridge to
+	// the global LoggerProvider, under the instrumentation scope Name.
+	OTel bool
+	Name string
+}
+
+// New returns a logger that stamps every record with the trace and span IDs,
+// the request ID and the CEP found in the context it is given.
+func New(opts Options) *slog.Logger {
+	writer := opts.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: opts.Level}
+	var handler slog.Handler = slog.NewJSONHandler(writer, handlerOptions)
+	if strings.EqualFold(opts.Format, "text") {
+		handler = slog.NewTextHandler(writer, handlerOptions)
+	}
+
+	if opts.OTel {
+		handler = fanoutHandler{handler, otelslog.NewHandler(opts.Name)}
+	}
+
+	return slog.New(contextHandler{handler})
+}
+
+// Discard returns a logger that drops every record.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// ParseLevel parses a level name such as "debug" or "warn", falling back to
+// info for anything it does not recognize.
+func ParseLevel(value string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+type cepKey struct{}
+
+// WithCEP attaches cep to ctx so every record logged with it carries the CEP.
+func WithCEP(ctx context.Context, cep string) context.Context {
+	return context.WithValue(ctx, cepKey{}, cep)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if cep, ok := ctx.Value(cepKey{}).(string); ok {
+		record.AddAttrs(slog.String("cep", cep))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// fanoutHandler hands every record to each of its handlers.
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, handler := range h {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
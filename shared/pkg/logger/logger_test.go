@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func decode(t *testing.T, buffer *bytes.Buffer) map[string]any {
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	return record
+}
+
+func TestNewStampsContext(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(Options{Writer: &buffer})
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+	ctx = context.WithValue(ctx, middleware.RequestIDKey, "request-1")
+	ctx = WithCEP(ctx, "01001-000")
+
+	logger.InfoContext(ctx, "hello", "key", "value")
+
+	record := decode(t, &buffer)
+	assert.Equal(t, "hello", record["msg"])
+	assert.Equal(t, "value", record["key"])
+	assert.Equal(t, span.SpanContext().TraceID().String(), record["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), record["span_id"])
+	assert.Equal(t, "request-1", record["request_id"])
+	assert.Equal(t, "01001-000", record["cep"])
+}
+
+func TestNewWithoutContext(t *testing.T) {
+	var buffer bytes.Buffer
+	New(Options{Writer: &buffer}).Info("hello")
+
+	record := decode(t, &buffer)
+	assert.NotContains(t, record, "trace_id")
+	assert.NotContains(t, record, "request_id")
+	assert.NotContains(t, record, "cep")
+}
+
+func TestNewTextFormat(t *testing.T) {
+	var buffer bytes.Buffer
+	New(Options{Writer: &buffer, Format: "text", Level: slog.LevelWarn}).Info("hidden")
+	assert.Empty(t, buffer.String())
+
+	New(Options{Writer: &buffer, Format: "text"}).Info("hello")
+	assert.Contains(t, buffer.String(), "msg=hello")
+}
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	assert.Equal(t, slog.LevelWarn, ParseLevel("WARN"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel(""))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("verbose"))
+}
+
+func TestMiddleware(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := Middleware(New(Options{Writer: &buffer}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?cep=01001000", nil))
+
+	record := decode(t, &buffer)
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "/", record["path"])
+	assert.Equal(t, float64(http.StatusServiceUnavailable), record["status"])
+}
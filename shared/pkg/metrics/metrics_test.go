@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func setupReader(t *testing.T) *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	return reader
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	aggregations := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			aggregations[m.Name] = m.Data
+		}
+	}
+	return aggregations
+}
+
+func TestMiddleware(t *testing.T) {
+	reader := setupReader(t)
+
+	router := chi.NewRouter()
+	router.Use(Middleware())
+	router.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/2", nil))
+
+	aggregations := collect(t, reader)
+
+	histogram := aggregations["http.server.request.duration"].(metricdata.Histogram[float64])
+	require.Len(t, histogram.DataPoints, 1)
+	point := histogram.DataPoints[0]
+	assert.Equal(t, uint64(2), point.Count)
+	value, _ := point.Attributes.Value("http.route")
+	assert.Equal(t, "/items/{id}", value.AsString())
+	value, _ = point.Attributes.Value("http.response.status_code")
+	assert.Equal(t, int64(http.StatusNotFound), value.AsInt64())
+
+	active := aggregations["http.server.active_requests"].(metricdata.Sum[int64])
+	require.Len(t, active.DataPoints, 1)
+	assert.Equal(t, int64(0), active.DataPoints[0].Value)
+}
+
+func TestTransport(t *testing.T) {
+	reader := setupReader(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport("viacep", nil)}
+	res, err := client.Get(server.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	aggregations := collect(t, reader)
+
+	histogram := aggregations["upstream.request.duration"].(metricdata.Histogram[float64])
+	require.Len(t, histogram.DataPoints, 1)
+	value, _ := histogram.DataPoints[0].Attributes.Value("upstream.name")
+	assert.Equal(t, "viacep", value.AsString())
+
+	failures := aggregations["upstream.request.errors"].(metricdata.Sum[int64])
+	require.Len(t, failures.DataPoints, 1)
+	assert.Equal(t, int64(1), failures.DataPoints[0].Value)
+	assert.True(t, failures.DataPoints[0].Attributes.HasValue(attribute.Key("error.type")))
+}
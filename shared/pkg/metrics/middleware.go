@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Middleware records the duration of every request, by route and status, and
+// the number of requests in flight.
+func Middleware() func(http.Handler) http.Handler {
+	meter := otel.Meter(viper.GetString("SERVICE_NAME"))
+
+	duration, err := meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	active, err := meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithDescription("Number of HTTP server requests in flight."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			method := attribute.String("http.request.method", r.Method)
+
+			active.Add(ctx, 1, metric.WithAttributes(method))
+			defer active.Add(ctx, -1, metric.WithAttributes(method))
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+				method,
+				attribute.String("http.route", route(r)),
+				attribute.Int("http.response.status_code", status),
+			))
+		})
+	}
+}
+
+// route returns the pattern chi matched, keeping the cardinality of the
+// http.route attribute bounded.
+func route(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
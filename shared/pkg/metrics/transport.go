@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Transport records the latency and the failures of the calls made to an
+// upstream dependency. Each attempt is recorded on its own.
+type Transport struct {
+	dependency attribute.KeyValue
+	next       http.RoundTripper
+	duration   metric.Float64Histogram
+	errors     metric.Int64Counter
+}
+
+func NewTransport(dependency string, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	meter := otel.Meter(viper.GetString("SERVICE_NAME"))
+
+	duration, err := meter.Float64Histogram("upstream.request.duration",
+		metric.WithDescription("Duration of the calls made to upstream dependencies."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	failures, err := meter.Int64Counter("upstream.request.errors",
+		metric.WithDescription("Calls to upstream dependencies that failed or answered with a server error."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &Transport{
+		dependency: attribute.String("upstream.name", dependency),
+		next:       next,
+		duration:   duration,
+		errors:     failures,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+
+	res, err := t.next.RoundTrip(req)
+
+	attrs := []attribute.KeyValue{t.dependency, attribute.String("http.request.method", req.Method)}
+	switch {
+	case err != nil:
+		t.errors.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("error.type", errorType(err)))...))
+	case res.StatusCode >= http.StatusInternalServerError:
+		attrs = append(attrs, attribute.Int("http.response.status_code", res.StatusCode))
+		t.errors.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("error.type", strconv.Itoa(res.StatusCode)))...))
+	default:
+		attrs = append(attrs, attribute.Int("http.response.status_code", res.StatusCode))
+	}
+
+	t.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	return res, err
+}
+
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "transport"
+}
@@ -0,0 +1,40 @@
+package propagators
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// baggageProcessor copies baggage entries onto the spans started under them.
+type baggageProcessor struct {
+	keys []string
+}
+
+// NewBaggageProcessor sets the baggage entries named by keys, such as a client
+// ID sent by the caller, as attributes of every span started in their
+// context. Entries that are absent are left out.
+func NewBaggageProcessor(keys ...string) sdktrace.SpanProcessor {
+	return &baggageProcessor{keys: keys}
+}
+
+func (p *baggageProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	bag := baggage.FromContext(parent)
+	for _, key := range p.keys {
+		if member := bag.Member(key); member.Key() != "" {
+			s.SetAttributes(attribute.String(key, member.Value()))
+		}
+	}
+}
+
+func (p *baggageProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (p *baggageProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (p *baggageProcessor) ForceFlush(context.Context) error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package propagators
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagators accepted by New.
+const (
+	TraceContext = "tracecontext"
+	Baggage      = "baggage"
+	B3           = "b3"
+	B3Multi      = "b3multi"
+	Jaeger       = "jaeger"
+)
+
+// New combines the named propagators. Incoming requests are read with each of
+// them in order, so a later one wins when several headers are present, and
+// outgoing requests carry the headers of all of them.
+func New(names ...string) (propagation.TextMapPropagator, error) {
+	propagators := make([]propagation.TextMapPropagator, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case TraceContext:
+			propagators = append(propagators, propagation.TraceContext{})
+		case Baggage:
+			propagators = append(propagators, propagation.Baggage{})
+		case B3:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case B3Multi:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case Jaeger:
+			propagators = append(propagators, jaeger.Jaeger{})
+		default:
+			return nil, fmt.Errorf("unknown propagator %q", name)
+		}
+	}
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
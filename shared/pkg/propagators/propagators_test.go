@@ -0,0 +1,93 @@
+package propagators
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewExtracts(t *testing.T) {
+	propagator, err := New(TraceContext, Baggage, B3, B3Multi, Jaeger)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		header http.Header
+	}{
+		{name: "tracecontext", header: http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}},
+		{name: "b3 single header", header: http.Header{"B3": {"4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1"}}},
+		{name: "b3 multiple headers", header: http.Header{
+			"X-B3-Traceid": {"4bf92f3577b34da6a3ce929d0e0e4736"},
+			"X-B3-Spanid":  {"00f067aa0ba902b7"},
+			"X-B3-Sampled": {"1"},
+		}},
+		{name: "jaeger", header: http.Header{"Uber-Trace-Id": {"4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := propagator.Extract(context.Background(), propagation.HeaderCarrier(tc.header))
+
+			sc := trace.SpanContextFromContext(ctx)
+			assert.True(t, sc.IsRemote())
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID().String())
+			assert.Equal(t, "00f067aa0ba902b7", sc.SpanID().String())
+		})
+	}
+}
+
+func TestNewInjectsEveryFormat(t *testing.T) {
+	propagator, err := New(TraceContext, B3, B3Multi)
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	header := http.Header{}
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+
+	assert.NotEmpty(t, header.Get("traceparent"))
+	assert.NotEmpty(t, header.Get("b3"))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", header.Get("x-b3-traceid"))
+}
+
+func TestNewRejectsUnknownPropagator(t *testing.T) {
+	_, err := New(TraceContext, "xray")
+	assert.EqualError(t, err, `unknown propagator "xray"`)
+}
+
+func TestBaggageProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(NewBaggageProcessor("client.id", "tenant.id")),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	member, err := baggage.NewMember("client.id", "mobile-app")
+	require.NoError(t, err)
+	secret, err := baggage.NewMember("session", "secret")
+	require.NoError(t, err)
+	bag, err := baggage.New(member, secret)
+	require.NoError(t, err)
+
+	_, span := provider.Tracer("test").Start(baggage.ContextWithBaggage(context.Background(), bag), "span")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("client.id", "mobile-app")}, spans[0].Attributes())
+}
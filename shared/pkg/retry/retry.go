@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy describes how failed upstream calls are retried.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, the first one included.
+	MaxAttempts int
+	// BaseDelay is the wait before the second attempt, doubled on each
+	// following one up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is the fraction, between 0 and 1, of each delay that is
+	// randomized so that clients do not retry in lockstep.
+	Jitter float64
+	// RetryableStatusCodes are the response codes worth another attempt.
+	// Transport errors are always retried.
+	RetryableStatusCodes []int
+	// RetryableResponse, when set, is asked about every response with a
+	// retryable status and vetoes the retry by returning false, for instance
+	// when the body tells the failure will not go away.
+	RetryableResponse func(res *http.Response) bool
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:          3,
+		BaseDelay:            100 * time.Millisecond,
+		MaxDelay:             2 * time.Second,
+		Jitter:               0.5,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// Backoff returns how long to wait after the given failed attempt,
+// starting at 1.
+func (p Policy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+func (p Policy) retryableStatus(statusCode int) bool {
+	for _, code := range p.RetryableStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStatusCodes parses a comma separated list of HTTP status codes,
+// skipping the entries that are not one.
+func ParseStatusCodes(value string) []int {
+	var codes []int
+	for _, field := range strings.Split(value, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(field))
+		if err == nil && code >= 100 && code <= 599 {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an
+// HTTP date.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,142 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func testPolicy() Policy {
+	policy := DefaultPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Millisecond
+	return policy
+}
+
+func newServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		status := statuses[len(statuses)-1]
+		if call <= len(statuses) {
+			status = statuses[call-1]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 400*time.Millisecond, policy.Backoff(3))
+	assert.Equal(t, time.Second, policy.Backoff(10))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.Backoff(1)
+		assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+		assert.LessOrEqual(t, delay, 100*time.Millisecond)
+	}
+}
+
+func TestTransportRetriesRetryableStatus(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	server, calls := newServer(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Len(t, recorder.Ended(), 3)
+}
+
+func TestTransportGivesUpAfterMaxAttempts(t *testing.T) {
+	server, calls := newServer(t, http.StatusServiceUnavailable)
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestTransportDoesNotRetryOtherStatus(t *testing.T) {
+	server, calls := newServer(t, http.StatusInternalServerError, http.StatusOK)
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestTransportAsksRetryableResponse(t *testing.T) {
+	server, calls := newServer(t, http.StatusServiceUnavailable)
+	policy := testPolicy()
+	policy.RetryableResponse = func(res *http.Response) bool { return false }
+	client := &http.Client{Transport: NewTransport(policy, nil)}
+
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestParseStatusCodes(t *testing.T) {
+	assert.Equal(t, []int{502, 503}, ParseStatusCodes(" 502,503,,abc,42"))
+	assert.Empty(t, ParseStatusCodes(""))
+}
+
+func TestTransportRespectsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+
+	start := time.Now()
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestTransportStopsAtDeadline(t *testing.T) {
+	server, calls := newServer(t, http.StatusServiceUnavailable)
+	policy := testPolicy()
+	policy.BaseDelay = time.Second
+	policy.MaxDelay = time.Second
+	policy.Jitter = 0
+	client := &http.Client{Transport: NewTransport(policy, nil)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	res, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
@@ -0,0 +1,120 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kameikay/shared/pkg/deadline"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Transport retries requests according to a Policy. Each attempt is a child
+// span of the request, and no attempt is started when its delay would not
+// fit in what remains of the request context's deadline.
+type Transport struct {
+	policy Policy
+	next   http.RoundTripper
+}
+
+func NewTransport(policy Policy, next http.RoundTripper) *Transport {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Transport{
+		policy: policy,
+		next:   next,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 1; ; attempt++ {
+		res, err := t.attempt(ctx, req, attempt)
+
+		last := attempt >= t.policy.MaxAttempts || !replayable
+		if last || !t.retryable(res, err) {
+			return res, err
+		}
+
+		delay := t.policy.Backoff(attempt)
+		if res != nil {
+			if after, ok := retryAfter(res); ok {
+				delay = after
+			}
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return res, err
+		}
+
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+func (t *Transport) attempt(ctx context.Context, req *http.Request, attempt int) (*http.Response, error) {
+	tracer := otel.Tracer(viper.GetString("SERVICE_NAME"))
+	ctx, span := tracer.Start(ctx, "Retry.Attempt")
+	defer span.End()
+	span.SetAttributes(
+		attribute.Int("retry.attempt", attempt),
+		attribute.Int("retry.max_attempts", t.policy.MaxAttempts),
+		attribute.String("http.method", req.Method),
+		attribute.String("server.address", req.URL.Host),
+	)
+	deadline.Annotate(ctx, span)
+
+	res, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+	}
+	return res, nil
+}
+
+func (t *Transport) retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if !t.policy.retryableStatus(res.StatusCode) {
+		return false
+	}
+	return t.policy.RetryableResponse == nil || t.policy.RetryableResponse(res)
+}
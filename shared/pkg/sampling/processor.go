@@ -0,0 +1,46 @@
+package sampling
+
+import (
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewErrorProcessor wraps next, usually a batch span processor, so that the
+// traces recorded without being sampled are exported whole when one of their
+// spans ends in error. It buffers the spans of each trace like
+// NewTailProcessor with the default options, then exports the head sampled
+// traces and the failed ones.
+//
+// Only the spans of this process are buffered, so another service taking part
+// in a failed trace exports its own spans only if they failed as well.
+func NewErrorProcessor(next sdktrace.SpanProcessor) sdktrace.SpanProcessor {
+	p := newTailProcessor(next, DefaultTailOptions())
+	p.policy = keepSampledOrFailed
+	return p
+}
+
+func keepSampledOrFailed(t *pendingTrace) (string, bool) {
+	sampled := false
+	for _, s := range t.spans {
+		if s.Status().Code == codes.Error {
+			return ReasonError, true
+		}
+		sampled = sampled || s.SpanContext().IsSampled()
+	}
+
+	if sampled {
+		return ReasonSampled, true
+	}
+	return ReasonSampledOut, false
+}
+
+// sampledSpan reports a recorded span as sampled, which exporting processors
+// require.
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	return s.ReadOnlySpan.SpanContext().WithTraceFlags(s.ReadOnlySpan.SpanContext().TraceFlags().WithSampled(true))
+}
@@ -0,0 +1,146 @@
+package sampling
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Rule fixes the sampling decision of the server spans of requests to Path,
+// regardless of any other sampler.
+type Rule struct {
+	Path   string
+	Sample bool
+}
+
+type routeRules struct {
+	rules    []Rule
+	fallback sdktrace.Sampler
+}
+
+// RouteRules applies the first rule matching the path of a server span and
+// defers to fallback for every other span.
+func RouteRules(rules []Rule, fallback sdktrace.Sampler) sdktrace.Sampler {
+	return &routeRules{rules: rules, fallback: fallback}
+}
+
+func (s *routeRules) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if p.Kind == trace.SpanKindServer {
+		if path, ok := requestPath(p.Attributes); ok {
+			for _, rule := range s.rules {
+				if rule.Path != path {
+					continue
+				}
+				decision := sdktrace.Drop
+				if rule.Sample {
+					decision = sdktrace.RecordAndSample
+				}
+				return sdktrace.SamplingResult{
+					Decision:   decision,
+					Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+				}
+			}
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+func (s *routeRules) Description() string {
+	rules := make([]string, len(s.rules))
+	for i, rule := range s.rules {
+		decision := "drop"
+		if rule.Sample {
+			decision = "sample"
+		}
+		rules[i] = rule.Path + ":" + decision
+	}
+	return fmt.Sprintf("RouteRules{%s;%s}", strings.Join(rules, ","), s.fallback.Description())
+}
+
+func requestPath(attrs []attribute.KeyValue) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Key == "url.path" || attr.Key == "http.target" {
+			return attr.Value.AsString(), true
+		}
+	}
+	return "", false
+}
+
+type rateLimited struct {
+	perSecond float64
+	next      sdktrace.Sampler
+	now       func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// RateLimited lets at most perSecond of the spans sampled by next through,
+// with bursts of up to one second worth of spans. The others are dropped.
+func RateLimited(perSecond float64, next sdktrace.Sampler) sdktrace.Sampler {
+	return &rateLimited{
+		perSecond: perSecond,
+		next:      next,
+		now:       time.Now,
+		tokens:    max(perSecond, 1),
+	}
+}
+
+func (s *rateLimited) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.next.ShouldSample(p)
+	if result.Decision != sdktrace.RecordAndSample || s.take() {
+		return result
+	}
+
+	result.Decision = sdktrace.Drop
+	return result
+}
+
+func (s *rateLimited) take() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.last.IsZero() {
+		s.tokens = min(s.tokens+now.Sub(s.last).Seconds()*s.perSecond, max(s.perSecond, 1))
+	}
+	s.last = now
+
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+func (s *rateLimited) Description() string {
+	return fmt.Sprintf("RateLimited{%g/s;%s}", s.perSecond, s.next.Description())
+}
+
+type recordDropped struct {
+	next sdktrace.Sampler
+}
+
+// RecordDropped records the spans next drops instead of discarding them, so
+// that NewErrorProcessor can still export the traces in which one fails.
+func RecordDropped(next sdktrace.Sampler) sdktrace.Sampler {
+	return &recordDropped{next: next}
+}
+
+func (s *recordDropped) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.next.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+func (s *recordDropped) Description() string {
+	return fmt.Sprintf("RecordDropped{%s}", s.next.Description())
+}
@@ -0,0 +1,194 @@
+package sampling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func serverSpan(path string) sdktrace.SamplingParameters {
+	return sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		Kind:          trace.SpanKindServer,
+		Attributes:    []attribute.KeyValue{attribute.String("url.path", path)},
+	}
+}
+
+func TestRouteRules(t *testing.T) {
+	sampler := RouteRules([]Rule{{Path: "/healthz"}, {Path: "/debug", Sample: true}}, sdktrace.TraceIDRatioBased(0))
+
+	assert.Equal(t, sdktrace.Drop, sampler.ShouldSample(serverSpan("/healthz")).Decision)
+	assert.Equal(t, sdktrace.RecordAndSample, sampler.ShouldSample(serverSpan("/debug")).Decision)
+	assert.Equal(t, sdktrace.Drop, sampler.ShouldSample(serverSpan("/")).Decision)
+	assert.Equal(t, "RouteRules{/healthz:drop,/debug:sample;TraceIDRatioBased{0}}", sampler.Description())
+}
+
+func TestRateLimited(t *testing.T) {
+	now := time.Now()
+	sampler := RateLimited(2, sdktrace.AlwaysSample()).(*rateLimited)
+	sampler.now = func() time.Time { return now }
+
+	params := serverSpan("/")
+	assert.Equal(t, sdktrace.RecordAndSample, sampler.ShouldSample(params).Decision)
+	assert.Equal(t, sdktrace.RecordAndSample, sampler.ShouldSample(params).Decision)
+	assert.Equal(t, sdktrace.Drop, sampler.ShouldSample(params).Decision)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, sdktrace.RecordAndSample, sampler.ShouldSample(params).Decision)
+	assert.Equal(t, sdktrace.Drop, sampler.ShouldSample(params).Decision)
+}
+
+func TestRecordDropped(t *testing.T) {
+	assert.Equal(t, sdktrace.RecordOnly, RecordDropped(sdktrace.NeverSample()).ShouldSample(serverSpan("/")).Decision)
+	assert.Equal(t, sdktrace.RecordAndSample, RecordDropped(sdktrace.AlwaysSample()).ShouldSample(serverSpan("/")).Decision)
+}
+
+func TestErrorProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(RecordDropped(sdktrace.NeverSample())),
+		sdktrace.WithSpanProcessor(NewErrorProcessor(recorder)),
+	)
+	tracer := provider.Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	ok.End()
+
+	_, failed := tracer.Start(context.Background(), "failed")
+	failed.SetStatus(codes.Error, "boom")
+	failed.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "failed", spans[0].Name())
+	assert.True(t, spans[0].SpanContext().IsSampled())
+}
+
+func TestErrorProcessorExportsWholeTraces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(RecordDropped(sdktrace.NeverSample())),
+		sdktrace.WithSpanProcessor(NewErrorProcessor(recorder)),
+	)
+	tracer := provider.Tracer("test")
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	assert.Empty(t, recorder.Ended())
+	parent.End()
+
+	ctx, ok := tracer.Start(context.Background(), "ok")
+	_, okChild := tracer.Start(ctx, "ok child")
+	okChild.End()
+	ok.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, []string{"child", "parent"}, spanNames(spans))
+	for _, span := range spans {
+		assert.True(t, span.SpanContext().IsSampled())
+	}
+}
+
+func tailTracer(sampler sdktrace.Sampler, opts TailOptions) (trace.Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(NewTailProcessor(recorder, opts)),
+	)
+	return provider.Tracer("test"), recorder
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name()
+	}
+	return names
+}
+
+func TestTailProcessorKeepsErrorsAndSlowTraces(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	tracer, recorder := tailTracer(RecordDropped(sdktrace.NeverSample()), TailOptions{LatencyThreshold: time.Second})
+
+	ctx, root := tracer.Start(context.Background(), "failed")
+	_, child := tracer.Start(ctx, "failed.child")
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+	assert.Empty(t, recorder.Ended(), "spans are held until the trace completes")
+	root.End()
+
+	_, fast := tracer.Start(context.Background(), "fast")
+	fast.End()
+
+	start := time.Now()
+	_, slow := tracer.Start(context.Background(), "slow", trace.WithTimestamp(start))
+	slow.End(trace.WithTimestamp(start.Add(2 * time.Second)))
+
+	spans := recorder.Ended()
+	assert.Equal(t, []string{"failed.child", "failed", "slow"}, spanNames(spans))
+	for _, span := range spans {
+		assert.True(t, span.SpanContext().IsSampled())
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, point := range sum.DataPoints {
+					reason, _ := point.Attributes.Value("reason")
+					counts[m.Name+":"+reason.AsString()] += point.Value
+				}
+			}
+		}
+	}
+	assert.Equal(t, int64(1), counts["tail_sampling.traces.kept:"+ReasonError])
+	assert.Equal(t, int64(1), counts["tail_sampling.traces.kept:"+ReasonLatency])
+	assert.Equal(t, int64(1), counts["tail_sampling.traces.dropped:"+ReasonSampledOut])
+}
+
+func TestTailProcessorPercentage(t *testing.T) {
+	tracer, recorder := tailTracer(sdktrace.AlwaysSample(), TailOptions{Percentage: 100})
+	_, span := tracer.Start(context.Background(), "sampled")
+	span.End()
+	assert.Len(t, recorder.Ended(), 1)
+
+	tracer, recorder = tailTracer(RecordDropped(sdktrace.NeverSample()), TailOptions{Percentage: 100})
+	_, span = tracer.Start(context.Background(), "recorded")
+	span.End()
+	assert.Empty(t, recorder.Ended(), "only head sampled traces count towards the percentage")
+}
+
+func TestTailProcessorBoundsBufferedTraces(t *testing.T) {
+	tracer, recorder := tailTracer(sdktrace.AlwaysSample(), TailOptions{MaxTraces: 1})
+
+	ctx, first := tracer.Start(context.Background(), "first")
+	_, child := tracer.Start(ctx, "first.child")
+	child.SetStatus(codes.Error, "boom")
+	child.End()
+
+	// Starting a second trace evicts the first one, which is kept for its
+	// error, and the root span ending afterwards follows that decision.
+	_, second := tracer.Start(context.Background(), "second")
+	assert.Equal(t, []string{"first.child"}, spanNames(recorder.Ended()))
+
+	first.End()
+	second.End()
+	assert.Equal(t, []string{"first.child", "first"}, spanNames(recorder.Ended()))
+}
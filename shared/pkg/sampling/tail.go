@@ -0,0 +1,318 @@
+package sampling
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Reasons recorded with the decisions of the tail processor.
+const (
+	ReasonError      = "error"
+	ReasonLatency    = "latency"
+	ReasonPercentage = "percentage"
+	ReasonSampled    = "sampled"
+	ReasonSampledOut = "sampled_out"
+)
+
+// TailOptions configures NewTailProcessor. Zero durations and sizes fall back
+// to the defaults of DefaultTailOptions.
+type TailOptions struct {
+	// LatencyThreshold keeps the traces lasting longer than it.
+	LatencyThreshold time.Duration
+	// Percentage of the remaining head sampled traces that is kept, from 0
+	// to 100. The choice is derived from the trace ID, so every service keeps
+	// the same traces.
+	Percentage float64
+	// MaxTraces bounds the traces buffered at once. When it is reached the
+	// oldest trace is decided early to make room.
+	MaxTraces int
+	// MaxSpansPerTrace bounds the spans buffered for a trace. A trace reaching
+	// it is decided early, and its later spans follow that decision.
+	MaxSpansPerTrace int
+	// DecisionWait is the longest a trace is buffered, so that spans that are
+	// never ended do not hold the others back.
+	DecisionWait time.Duration
+}
+
+func DefaultTailOptions() TailOptions {
+	return TailOptions{
+		LatencyThreshold: time.Second,
+		Percentage:       10,
+		MaxTraces:        10000,
+		MaxSpansPerTrace: 1000,
+		DecisionWait:     30 * time.Second,
+	}
+}
+
+// pendingTrace holds the spans of a trace until it is decided.
+type pendingTrace struct {
+	id      trace.TraceID
+	started time.Time
+	open    int
+	spans   []sdktrace.ReadOnlySpan
+	keep    bool
+	reason  string
+}
+
+type tailProcessor struct {
+	next     sdktrace.SpanProcessor
+	opts     TailOptions
+	sampler  sdktrace.Sampler
+	now      func() time.Time
+	kept     metric.Int64Counter
+	dropped  metric.Int64Counter
+	buffered metric.Int64UpDownCounter
+	// policy decides whether a trace is exported, and why.
+	policy func(t *pendingTrace) (string, bool)
+
+	mu      sync.Mutex
+	traces  map[trace.TraceID]*list.Element
+	order   *list.List
+	decided map[trace.TraceID]bool
+	history *list.List
+}
+
+// NewTailProcessor buffers the recorded spans of each trace and hands them to
+// next, usually a batch span processor, once every span of the trace started
+// in this process has ended. The whole trace is exported when a span ended
+// with an error status, when it lasted longer than the latency threshold, or
+// when it falls in the configured percentage of the head sampled traces.
+// Everything else is dropped and counted in the tail_sampling.traces.dropped
+// metric.
+//
+// Spans are only seen by the processor when they are recorded, so the head
+// sampler should record the spans it does not sample for slow and failed
+// traces to be caught among them.
+func NewTailProcessor(next sdktrace.SpanProcessor, opts TailOptions) sdktrace.SpanProcessor {
+	p := newTailProcessor(next, opts)
+	p.policy = p.evaluate
+	return p
+}
+
+// newTailProcessor builds the buffering shared by the tail and error
+// processors. The caller sets the policy.
+func newTailProcessor(next sdktrace.SpanProcessor, opts TailOptions) *tailProcessor {
+	defaults := DefaultTailOptions()
+	if opts.LatencyThreshold <= 0 {
+		opts.LatencyThreshold = defaults.LatencyThreshold
+	}
+	if opts.MaxTraces <= 0 {
+		opts.MaxTraces = defaults.MaxTraces
+	}
+	if opts.MaxSpansPerTrace <= 0 {
+		opts.MaxSpansPerTrace = defaults.MaxSpansPerTrace
+	}
+	if opts.DecisionWait <= 0 {
+		opts.DecisionWait = defaults.DecisionWait
+	}
+	opts.Percentage = min(max(opts.Percentage, 0), 100)
+
+	p := &tailProcessor{
+		next:    next,
+		opts:    opts,
+		sampler: sdktrace.TraceIDRatioBased(opts.Percentage / 100),
+		now:     time.Now,
+		traces:  make(map[trace.TraceID]*list.Element),
+		order:   list.New(),
+		decided: make(map[trace.TraceID]bool),
+		history: list.New(),
+	}
+
+	meter := otel.Meter(viper.GetString("SERVICE_NAME"))
+
+	var err error
+	p.kept, err = meter.Int64Counter("tail_sampling.traces.kept",
+		metric.WithDescription("Traces exported by the tail sampler, by reason."),
+		metric.WithUnit("{trace}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	p.dropped, err = meter.Int64Counter("tail_sampling.traces.dropped",
+		metric.WithDescription("Traces dropped by the tail sampler."),
+		metric.WithUnit("{trace}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	p.buffered, err = meter.Int64UpDownCounter("tail_sampling.traces.buffered",
+		metric.WithDescription("Traces waiting for a tail sampling decision."),
+		metric.WithUnit("{trace}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return p
+}
+
+func (p *tailProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+
+	id := s.SpanContext().TraceID()
+
+	p.mu.Lock()
+	var ready []*pendingTrace
+	if _, ok := p.decided[id]; !ok {
+		elem, ok := p.traces[id]
+		if !ok {
+			if p.order.Len() >= p.opts.MaxTraces {
+				ready = append(ready, p.remove(p.order.Front()))
+			}
+			elem = p.order.PushBack(&pendingTrace{id: id, started: p.now()})
+			p.traces[id] = elem
+			p.buffered.Add(context.Background(), 1)
+		}
+		elem.Value.(*pendingTrace).open++
+	}
+	ready = append(ready, p.expired()...)
+	p.mu.Unlock()
+
+	p.decide(ready)
+}
+
+func (p *tailProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	id := s.SpanContext().TraceID()
+
+	p.mu.Lock()
+	var ready []*pendingTrace
+	if elem, ok := p.traces[id]; ok {
+		t := elem.Value.(*pendingTrace)
+		t.open--
+		t.spans = append(t.spans, s)
+		if t.open <= 0 || len(t.spans) >= p.opts.MaxSpansPerTrace {
+			ready = append(ready, p.remove(elem))
+		}
+	} else if keep, ok := p.decided[id]; ok {
+		// The trace was decided before this span ended, most likely a
+		// background task that outlived the request.
+		p.mu.Unlock()
+		if keep {
+			p.export(s)
+		}
+		return
+	}
+	ready = append(ready, p.expired()...)
+	p.mu.Unlock()
+
+	p.decide(ready)
+}
+
+func (p *tailProcessor) Shutdown(ctx context.Context) error {
+	p.flush()
+	return p.next.Shutdown(ctx)
+}
+
+func (p *tailProcessor) ForceFlush(ctx context.Context) error {
+	p.flush()
+	return p.next.ForceFlush(ctx)
+}
+
+// flush decides every buffered trace with the spans ended so far.
+func (p *tailProcessor) flush() {
+	p.mu.Lock()
+	var ready []*pendingTrace
+	for p.order.Len() > 0 {
+		ready = append(ready, p.remove(p.order.Front()))
+	}
+	p.mu.Unlock()
+
+	p.decide(ready)
+}
+
+// expired removes the traces buffered for longer than the decision wait. It
+// must be called with the lock held.
+func (p *tailProcessor) expired() []*pendingTrace {
+	var ready []*pendingTrace
+	deadline := p.now().Add(-p.opts.DecisionWait)
+	for elem := p.order.Front(); elem != nil && elem.Value.(*pendingTrace).started.Before(deadline); elem = p.order.Front() {
+		ready = append(ready, p.remove(elem))
+	}
+	return ready
+}
+
+// remove takes a trace out of the buffer and decides it, remembering the
+// decision for the spans of the trace that are still to end. It must be
+// called with the lock held.
+func (p *tailProcessor) remove(elem *list.Element) *pendingTrace {
+	t := p.order.Remove(elem).(*pendingTrace)
+	delete(p.traces, t.id)
+	p.buffered.Add(context.Background(), -1)
+
+	t.reason, t.keep = p.policy(t)
+	p.remember(t.id, t.keep)
+	return t
+}
+
+// decide exports the spans of the kept traces and counts the decisions.
+func (p *tailProcessor) decide(traces []*pendingTrace) {
+	for _, t := range traces {
+		if !t.keep {
+			p.dropped.Add(context.Background(), 1, metric.WithAttributes(attribute.String("reason", t.reason)))
+			continue
+		}
+
+		p.kept.Add(context.Background(), 1, metric.WithAttributes(attribute.String("reason", t.reason)))
+		for _, s := range t.spans {
+			p.export(s)
+		}
+	}
+}
+
+func (p *tailProcessor) evaluate(t *pendingTrace) (string, bool) {
+	var (
+		start, end time.Time
+		sampled    bool
+	)
+	for _, s := range t.spans {
+		if s.Status().Code == codes.Error {
+			return ReasonError, true
+		}
+		if start.IsZero() || s.StartTime().Before(start) {
+			start = s.StartTime()
+		}
+		if s.EndTime().After(end) {
+			end = s.EndTime()
+		}
+		sampled = sampled || s.SpanContext().IsSampled()
+	}
+
+	if len(t.spans) > 0 && end.Sub(start) > p.opts.LatencyThreshold {
+		return ReasonLatency, true
+	}
+
+	if sampled && p.sampler.ShouldSample(sdktrace.SamplingParameters{TraceID: t.id}).Decision == sdktrace.RecordAndSample {
+		return ReasonPercentage, true
+	}
+
+	return ReasonSampledOut, false
+}
+
+// remember records the decision of a trace, forgetting the oldest decisions
+// past MaxTraces. It must be called with the lock held.
+func (p *tailProcessor) remember(id trace.TraceID, keep bool) {
+	p.decided[id] = keep
+	p.history.PushBack(id)
+	for p.history.Len() > p.opts.MaxTraces {
+		delete(p.decided, p.history.Remove(p.history.Front()).(trace.TraceID))
+	}
+}
+
+func (p *tailProcessor) export(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		s = sampledSpan{s}
+	}
+	p.next.OnEnd(s)
+}
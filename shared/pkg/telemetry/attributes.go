@@ -0,0 +1,132 @@
+// Package telemetry holds the attributes and events the services add to their
+// spans, so that every span of the temperature flow describes the request the
+// same way.
+package telemetry
+
+import (
+	"errors"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys of the temperature flow.
+const (
+	// CEPKey is the zipcode being looked up, as received.
+	CEPKey = attribute.Key("cep.code")
+	// CEPProviderKey names the zipcode provider that answered, or was asked.
+	CEPProviderKey = attribute.Key("cep.provider")
+	// CityKey, StateKey and IBGECodeKey describe the address the zipcode
+	// resolved to. CityKey is also the location handed to weather providers.
+	CityKey     = attribute.Key("address.city")
+	StateKey    = attribute.Key("address.state")
+	IBGECodeKey = attribute.Key("address.ibge_code")
+	// WeatherProviderKey names the weather provider that answered, or was
+	// asked.
+	WeatherProviderKey = attribute.Key("weather.provider")
+	// WeatherStaleKey is set when the reading was served past its cache TTL.
+	WeatherStaleKey          = attribute.Key("weather.stale")
+	TemperatureCelsiusKey    = attribute.Key("weather.temperature.celsius")
+	TemperatureFahrenheitKey = attribute.Key("weather.temperature.fahrenheit")
+	TemperatureKelvinKey     = attribute.Key("weather.temperature.kelvin")
+	// CacheResultKey is the outcome of a cache lookup: hit, stale, miss or
+	// bypass.
+	CacheResultKey = attribute.Key("cache.result")
+	// ValidationFieldKey and ValidationReasonKey describe why the input of a
+	// request was rejected.
+	ValidationFieldKey  = attribute.Key("validation.field")
+	ValidationReasonKey = attribute.Key("validation.reason")
+	// ErrorTypeKey is the code of a failure classified by the exceptions
+	// package.
+	ErrorTypeKey = attribute.Key("error.type")
+	// BatchSizeKey, BatchDistinctKey and BatchFailedKey describe a batch
+	// lookup: how many CEPs it held, how many different ones were looked up
+	// and how many of its CEPs failed.
+	BatchSizeKey     = attribute.Key("batch.size")
+	BatchDistinctKey = attribute.Key("batch.distinct")
+	BatchFailedKey   = attribute.Key("batch.failed")
+)
+
+// ValidationFailedEvent is added to a span when the input of the request is
+// rejected.
+const ValidationFailedEvent = "validation.failed"
+
+// Batch describes a batch lookup of size CEPs, distinct of them different.
+func Batch(size, distinct int) []attribute.KeyValue {
+	return []attribute.KeyValue{BatchSizeKey.Int(size), BatchDistinctKey.Int(distinct)}
+}
+
+func CEP(cep string) attribute.KeyValue {
+	return CEPKey.String(cep)
+}
+
+func CEPProvider(name string) attribute.KeyValue {
+	return CEPProviderKey.String(name)
+}
+
+func City(city string) attribute.KeyValue {
+	return CityKey.String(city)
+}
+
+// Address describes a resolved zipcode, leaving out the parts the provider
+// did not return.
+func Address(city, state, ibgeCode string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{City(city)}
+	if state != "" {
+		attrs = append(attrs, StateKey.String(state))
+	}
+	if ibgeCode != "" {
+		attrs = append(attrs, IBGECodeKey.String(ibgeCode))
+	}
+	return attrs
+}
+
+func WeatherProvider(name string) attribute.KeyValue {
+	return WeatherProviderKey.String(name)
+}
+
+func WeatherStale(stale bool) attribute.KeyValue {
+	return WeatherStaleKey.Bool(stale)
+}
+
+func TemperatureCelsius(celsius float64) attribute.KeyValue {
+	return TemperatureCelsiusKey.Float64(celsius)
+}
+
+// Temperature describes a reading in the three scales returned to clients.
+func Temperature(celsius, fahrenheit, kelvin float64) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		TemperatureCelsius(celsius),
+		TemperatureFahrenheitKey.Float64(fahrenheit),
+		TemperatureKelvinKey.Float64(kelvin),
+	}
+}
+
+func CacheResult(result string) attribute.KeyValue {
+	return CacheResultKey.String(result)
+}
+
+// RecordError records err on span and marks the span as failed, along with
+// its code when it is an exceptions.Error. It does nothing when err is nil.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	var e *exceptions.Error
+	if errors.As(err, &e) {
+		span.SetAttributes(ErrorTypeKey.String(string(e.Code)))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// ValidationFailed records that field was rejected for err. The span is not
+// marked as failed, the mistake being the caller's.
+func ValidationFailed(span trace.Span, field string, err error) {
+	span.AddEvent(ValidationFailedEvent, trace.WithAttributes(
+		ValidationFieldKey.String(field),
+		ValidationReasonKey.String(err.Error()),
+	))
+}
@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAddress(t *testing.T) {
+	assert.Equal(t, []attribute.KeyValue{
+		CityKey.String("São Paulo"),
+		StateKey.String("SP"),
+		IBGECodeKey.String("3550308"),
+	}, Address("São Paulo", "SP", "3550308"))
+	assert.Equal(t, []attribute.KeyValue{CityKey.String("São Paulo")}, Address("São Paulo", "", ""))
+}
+
+func TestRecordError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	RecordError(ok, nil)
+	ok.End()
+
+	_, failed := tracer.Start(context.Background(), "failed")
+	RecordError(failed, errors.New("boom"))
+	failed.End()
+
+	_, classified := tracer.Start(context.Background(), "classified")
+	RecordError(classified, fmt.Errorf("lookup: %w", exceptions.ErrCannotFindZipcode))
+	classified.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Empty(t, spans[0].Events())
+	assert.Equal(t, sdktrace.Status{Code: codes.Error, Description: "boom"}, spans[1].Status())
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "exception", spans[1].Events()[0].Name)
+	assert.Empty(t, spans[1].Attributes())
+	assert.Equal(t, []attribute.KeyValue{ErrorTypeKey.String("ZIPCODE_NOT_FOUND")}, spans[2].Attributes())
+}
+
+func TestValidationFailed(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "handler")
+	ValidationFailed(span, "cep", errors.New("invalid zipcode"))
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Len(t, spans[0].Events(), 1)
+	event := spans[0].Events()[0]
+	assert.Equal(t, ValidationFailedEvent, event.Name)
+	assert.Equal(t, []attribute.KeyValue{
+		ValidationFieldKey.String("cep"),
+		ValidationReasonKey.String("invalid zipcode"),
+	}, event.Attributes)
+}
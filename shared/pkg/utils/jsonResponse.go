@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/kameikay/shared/pkg/exceptions"
+)
+
+type Response struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    interface{}       `json:"data,omitempty"`
+	Error   *exceptions.Error `json:"error,omitempty"`
+}
+
+type ResponseDTO struct {
+	StatusCode int
+	Success    bool
+	Message    string
+	Data       interface{}
+	Error      *exceptions.Error
+}
+
+func JsonResponse(w http.ResponseWriter, response ResponseDTO) {
+	res := Response{
+		Message: response.Message,
+		Data:    response.Data,
+		Success: response.Success,
+		Error:   response.Error,
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+	w.Write(jsonResponse)
+}
+
+// ErrorResponse answers r with the status of err once classified. Clients
+// asking for application/problem+json get it described as a Problem, while
+// the others get it in the error field of the envelope so that they can
+// rebuild it.
+func ErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	e := exceptions.From(err)
+	w.Header().Add("Vary", "Accept")
+
+	if AcceptsProblem(r) {
+		ProblemResponse(w, NewProblem(r, e))
+		return
+	}
+
+	JsonResponse(w, ResponseDTO{
+		StatusCode: e.Status,
+		Message:    e.Message,
+		Success:    false,
+		Error:      e,
+	})
+}
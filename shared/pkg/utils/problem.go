@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an error described as in RFC 7807. Code, Retryable and TraceID
+// are extension members.
+type Problem struct {
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Status    int             `json:"status"`
+	Detail    string          `json:"detail,omitempty"`
+	Instance  string          `json:"instance,omitempty"`
+	Code      exceptions.Code `json:"code"`
+	Retryable bool            `json:"retryable"`
+	TraceID   string          `json:"trace_id,omitempty"`
+}
+
+// NewProblem describes e as the answer to r. The type is PROBLEM_TYPE_BASE_URL
+// followed by the code of e, titled after it, or about:blank titled after the
+// status when no base URL is configured. The cause of e is left out, as it is
+// over the envelope.
+func NewProblem(r *http.Request, e *exceptions.Error) Problem {
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Message,
+		Instance:  r.URL.RequestURI(),
+		Code:      e.Code,
+		Retryable: e.Retryable,
+	}
+
+	if base := viper.GetString("PROBLEM_TYPE_BASE_URL"); base != "" {
+		problem.Type = strings.TrimSuffix(base, "/") + "/" + strings.ToLower(strings.ReplaceAll(string(e.Code), "_", "-"))
+		problem.Title = e.Message
+	}
+	if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+		problem.TraceID = spanContext.TraceID().String()
+	}
+
+	return problem
+}
+
+// ProblemResponse answers with problem.
+func ProblemResponse(w http.ResponseWriter, problem Problem) {
+	jsonResponse, err := json.Marshal(problem)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(jsonResponse)
+}
+
+// AcceptsProblem tells whether r asks for application/problem+json rather
+// than application/json. Only clients naming problem+json explicitly get it,
+// so that those accepting anything keep the envelope.
+func AcceptsProblem(r *http.Request) bool {
+	problem, plain := 0.0, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case ProblemContentType:
+			problem = max(problem, quality)
+		case "application/json", "application/*", "*/*":
+			plain = max(plain, quality)
+		}
+	}
+
+	return problem > 0 && problem >= plain
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/kameikay/shared/pkg/exceptions"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAcceptsProblem(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected bool
+	}{
+		{accept: "", expected: false},
+		{accept: "*/*", expected: false},
+		{accept: "application/json", expected: false},
+		{accept: "application/problem+json", expected: true},
+		{accept: "application/json, application/problem+json", expected: true},
+		{accept: "application/problem+json;q=0.5, application/json", expected: false},
+		{accept: "application/problem+json, */*;q=0.1", expected: true},
+		{accept: "application/problem+json;q=0", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.accept, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "http://test/", nil)
+			request.Header.Set("Accept", tc.accept)
+			assert.Equal(t, tc.expected, AcceptsProblem(request))
+		})
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	defer viper.Reset()
+	viper.Set("PROBLEM_TYPE_BASE_URL", "https://errors.example.com/")
+
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{1},
+	}))
+	err := exceptions.Wrap(exceptions.ErrCEPServiceUnavailable, errors.New("viacep responded with status 500"))
+
+	t.Run("problem", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://test/?cep=01001000", nil).WithContext(ctx)
+		request.Header.Set("Accept", ProblemContentType)
+		recorder := httptest.NewRecorder()
+
+		ErrorResponse(recorder, request, err)
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, ProblemContentType, recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+		assert.JSONEq(t, `{
+			"type": "https://errors.example.com/cep-service-unavailable",
+			"title": "zipcode service unavailable",
+			"status": 503,
+			"detail": "zipcode service unavailable",
+			"instance": "/?cep=01001000",
+			"code": "CEP_SERVICE_UNAVAILABLE",
+			"retryable": true,
+			"trace_id": "`+traceID.String()+`"
+		}`, recorder.Body.String())
+	})
+
+	t.Run("envelope", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://test/?cep=01001000", nil).WithContext(ctx)
+		recorder := httptest.NewRecorder()
+
+		ErrorResponse(recorder, request, err)
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		require.Equal(t, "Accept", recorder.Header().Get("Vary"))
+		assert.JSONEq(t, `{
+			"success": false,
+			"message": "zipcode service unavailable",
+			"error": {
+				"code": "CEP_SERVICE_UNAVAILABLE",
+				"status": 503,
+				"retryable": true,
+				"message": "zipcode service unavailable"
+			}
+		}`, recorder.Body.String())
+	})
+}
+
+func TestErrorResponseLeavesCauseOut(t *testing.T) {
+	err := exceptions.Wrap(exceptions.ErrWeatherServiceUnavailable, &url.Error{
+		Op:  "Get",
+		URL: "http://api.weatherapi.com/v1/current.json?key=secret&q=Paris",
+		Err: errors.New("connection refused"),
+	})
+
+	for _, accept := range []string{"application/json", ProblemContentType} {
+		t.Run(accept, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "http://test/?cep=01001000", nil)
+			request.Header.Set("Accept", accept)
+			recorder := httptest.NewRecorder()
+
+			ErrorResponse(recorder, request, err)
+
+			assert.Equal(t, http.StatusBadGateway, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "weather service unavailable")
+			assert.NotContains(t, recorder.Body.String(), "secret")
+			assert.NotContains(t, recorder.Body.String(), "weatherapi.com")
+		})
+	}
+}
+
+func TestNewProblemWithoutTypeBaseURL(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "http://test/", nil)
+	problem := NewProblem(request, exceptions.ErrInvalidCEP)
+
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Unprocessable Entity", problem.Title)
+	assert.Equal(t, "/", problem.Instance)
+	assert.Empty(t, problem.TraceID)
+}
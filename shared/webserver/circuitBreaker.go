@@ -0,0 +1,27 @@
+package webserver
+
+import (
+	"net/http"
+
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/kameikay/shared/pkg/utils"
+)
+
+type CircuitBreakerHandler struct {
+	registry *breaker.Registry
+}
+
+func NewCircuitBreakerHandler(registry *breaker.Registry) *CircuitBreakerHandler {
+	return &CircuitBreakerHandler{
+		registry: registry,
+	}
+}
+
+func (h *CircuitBreakerHandler) GetCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	utils.JsonResponse(w, utils.ResponseDTO{
+		StatusCode: http.StatusOK,
+		Message:    http.StatusText(http.StatusOK),
+		Success:    true,
+		Data:       h.registry.Snapshots(),
+	})
+}
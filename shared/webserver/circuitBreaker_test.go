@@ -0,0 +1,24 @@
+package webserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kameikay/shared/pkg/breaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCircuitBreakers(t *testing.T) {
+	registry := breaker.NewRegistry()
+	registry.Get("viacep", breaker.Options{})
+
+	request := httptest.NewRequest(http.MethodGet, "http://test/circuit-breakers", nil)
+	recorder := httptest.NewRecorder()
+
+	handler := NewCircuitBreakerHandler(registry)
+	handler.GetCircuitBreakers(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"success":true,"message":"OK","data":[{"name":"viacep","state":"closed","failures":0,"failure_threshold":5}]}`, recorder.Body.String())
+}
@@ -0,0 +1,209 @@
+package webserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"github.com/kameikay/shared/pkg/deadline"
+	"github.com/kameikay/shared/pkg/health"
+	"github.com/kameikay/shared/pkg/logger"
+	"github.com/kameikay/shared/pkg/metrics"
+	"github.com/kameikay/shared/pkg/utils"
+)
+
+type WebServer struct {
+	Router        chi.Router
+	Handlers      []HandlerFunc
+	WebServerPort string
+	// RequestTimeout caps the deadline of every request, including the budget
+	// received from the caller. Zero leaves requests without a budget
+	// unbounded.
+	RequestTimeout time.Duration
+	// Logger receives the access log, one record per request.
+	Logger *slog.Logger
+	// ReadTimeout, WriteTimeout and IdleTimeout are passed on to the
+	// http.Server. WriteTimeout should leave room for RequestTimeout, or
+	// responses are cut off before the request gives up.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownDelay is how long the server keeps serving once it reports
+	// itself as not ready, so that load balancers stop routing to it before
+	// the listener is closed.
+	ShutdownDelay time.Duration
+	// Readiness holds the checks behind /readyz, on top of the server not
+	// shutting down.
+	Readiness *health.Checker
+	// Dependencies holds the probes of the upstream dependencies reported by
+	// /health/dependencies.
+	Dependencies *health.Checker
+
+	mu       sync.Mutex
+	server   *http.Server
+	stopping bool
+	ready    atomic.Bool
+}
+
+type HandlerFunc struct {
+	Method  string
+	Handler http.HandlerFunc
+}
+
+func NewWebServer(serverPort string) *WebServer {
+	return &WebServer{
+		Router:        chi.NewRouter(),
+		WebServerPort: serverPort,
+		Logger:        slog.Default(),
+		Readiness:     health.NewChecker(0, health.DefaultTimeout),
+		Dependencies:  health.NewChecker(health.DefaultTTL, health.DefaultTimeout),
+	}
+}
+
+func (s *WebServer) MountMiddlewares() {
+	// Middlewares
+	s.Router.Use(middleware.RequestID)
+	s.Router.Use(middleware.RealIP)
+	s.Router.Use(tracing)
+	s.Router.Use(logger.Middleware(s.Logger))
+	s.Router.Use(metrics.Middleware())
+	s.Router.Use(middleware.Recoverer)
+	s.Router.Use(deadline.Middleware(s.RequestTimeout))
+	s.Router.Use(middleware.AllowContentType("application/json"))
+	s.Router.Use(cors.Handler(cors.Options{
+		AllowedOrigins:   []string{"https://*", "http://*"}, // Use this to allow specific origin hosts
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: false,
+		MaxAge:           300, // 5 minutes
+	}))
+}
+
+// MountProbes adds the health routes: /healthz answers as long as the process
+// serves requests, /readyz runs the Readiness checks and fails once the
+// server starts shutting down, and /health/dependencies reports the
+// Dependencies. The last two answer 503 Service Unavailable when a check
+// fails.
+func (s *WebServer) MountProbes() {
+	s.Router.Get("/healthz", s.liveness)
+	s.Router.Get("/readyz", s.readiness)
+	s.Router.Get("/health/dependencies", s.dependencies)
+}
+
+// Ready reports whether the server is accepting new requests.
+func (s *WebServer) Ready() bool {
+	return s.ready.Load()
+}
+
+// Start listens on WebServerPort and serves requests until Shutdown is
+// called, when it returns nil.
+func (s *WebServer) Start() error {
+	listener, err := net.Listen("tcp", s.WebServerPort)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve serves requests on listener until Shutdown is called, when it returns
+// nil.
+func (s *WebServer) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return listener.Close()
+	}
+	server := &http.Server{
+		Handler:      s.Router,
+		ReadTimeout:  s.ReadTimeout,
+		WriteTimeout: s.WriteTimeout,
+		IdleTimeout:  s.IdleTimeout,
+	}
+	s.server = server
+	s.ready.Store(true)
+	s.mu.Unlock()
+
+	s.Logger.Info("starting web server", "port", s.WebServerPort)
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		s.ready.Store(false)
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown marks the server as not ready, waits for ShutdownDelay, then stops
+// accepting connections and waits for the requests in flight to complete.
+// When ctx expires first the remaining connections are closed and its error
+// is returned.
+func (s *WebServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopping = true
+	s.ready.Store(false)
+	server := s.server
+	s.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	s.Logger.Info("shutting down web server", "delay", s.ShutdownDelay)
+	select {
+	case <-time.After(s.ShutdownDelay):
+	case <-ctx.Done():
+	}
+
+	if err := server.Shutdown(ctx); err != nil {
+		s.Logger.Warn("requests still in flight at the shutdown deadline", "error", err)
+		return errors.Join(err, server.Close())
+	}
+
+	s.Logger.Info("web server stopped")
+	return nil
+}
+
+func (s *WebServer) liveness(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, health.Report{Status: health.StatusUp, Checks: []health.Result{}})
+}
+
+func (s *WebServer) readiness(w http.ResponseWriter, r *http.Request) {
+	report := s.Readiness.Run(r.Context())
+
+	server := health.Result{Name: "server", Status: health.StatusUp, CheckedAt: time.Now()}
+	if !s.Ready() {
+		server.Status = health.StatusDown
+		server.Error = "not serving"
+		report.Status = health.StatusDown
+	}
+	report.Checks = append([]health.Result{server}, report.Checks...)
+
+	writeReport(w, report)
+}
+
+func (s *WebServer) dependencies(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, s.Dependencies.Run(r.Context()))
+}
+
+func writeReport(w http.ResponseWriter, report health.Report) {
+	statusCode := http.StatusOK
+	if report.Status != health.StatusUp {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	utils.JsonResponse(w, utils.ResponseDTO{
+		StatusCode: statusCode,
+		Message:    http.StatusText(statusCode),
+		Success:    statusCode == http.StatusOK,
+		Data:       report,
+	})
+}
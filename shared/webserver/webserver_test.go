@@ -0,0 +1,238 @@
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kameikay/shared/pkg/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewWebServer(t *testing.T) {
+	webserver := NewWebServer(":8080")
+	assert.NotNil(t, webserver)
+}
+
+func TestMountMiddlewares(t *testing.T) {
+	webserver := NewWebServer(":8080")
+	webserver.MountMiddlewares()
+
+	router := chi.NewRouter()
+	router.Use(webserver.Router.Middlewares()...)
+
+	// Define a test route to test the middlewares
+	router.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Create a request to the test route
+	req, err := http.NewRequest("GET", "/test", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	// Create a recorder to record the response
+	rec := httptest.NewRecorder()
+
+	// Call the test route with the request and recorder
+	router.ServeHTTP(rec, req)
+
+	// Assert that the response has the expected status code
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestTracingNamesSpanAfterRoute(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	router := chi.NewRouter()
+	router.Use(tracing)
+	router.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "GET /items/{id}", spans[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+// serve starts webserver on a free port and returns its URL along with the
+// error returned by Serve.
+func serve(t *testing.T, webserver *WebServer) (string, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- webserver.Serve(listener)
+	}()
+	require.Eventually(t, webserver.Ready, time.Second, 10*time.Millisecond)
+
+	return "http://" + listener.Addr().String(), served
+}
+
+func TestReadinessProbe(t *testing.T) {
+	webserver := NewWebServer(":8080")
+	webserver.MountProbes()
+	url, served := serve(t, webserver)
+
+	res, err := http.Get(url + "/readyz")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	webserver.ShutdownDelay = 200 * time.Millisecond
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- webserver.Shutdown(context.Background())
+	}()
+	require.Eventually(t, func() bool { return !webserver.Ready() }, time.Second, time.Millisecond)
+
+	// The server keeps serving during the delay, but reports itself as not
+	// ready.
+	res, err = http.Get(url + "/readyz")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+	assert.NoError(t, <-shutdown)
+	assert.NoError(t, <-served)
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	webserver := NewWebServer(":8080")
+	webserver.Router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	url, served := serve(t, webserver)
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.Get(url + "/slow")
+		assert.NoError(t, err)
+		responses <- res
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- webserver.Shutdown(context.Background())
+	}()
+
+	// New connections are refused while the request in flight is drained.
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", url[len("http://"):])
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, shutdown)
+
+	close(release)
+	res := <-responses
+	require.NotNil(t, res)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NoError(t, <-shutdown)
+	assert.NoError(t, <-served)
+}
+
+func TestShutdownClosesConnectionsAtDeadline(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	webserver := NewWebServer(":8080")
+	webserver.Router.Get("/stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	url, served := serve(t, webserver)
+
+	go func() {
+		res, err := http.Get(url + "/stuck")
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, webserver.Shutdown(ctx), context.DeadlineExceeded)
+	assert.NoError(t, <-served)
+}
+
+func TestShutdownBeforeServe(t *testing.T) {
+	webserver := NewWebServer(":8080")
+	assert.NoError(t, webserver.Shutdown(context.Background()))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	assert.NoError(t, webserver.Serve(listener))
+	assert.False(t, webserver.Ready())
+}
+
+func TestHealthRoutes(t *testing.T) {
+	webserver := NewWebServer(":8080")
+	webserver.MountProbes()
+	webserver.Readiness.Add("collector", func(context.Context) error { return nil })
+	webserver.Dependencies.Add("viacep", func(context.Context) error { return nil })
+	webserver.Dependencies.Add("weatherapi", func(context.Context) error { return errors.New("responded with status 401") })
+	url, served := serve(t, webserver)
+
+	get := func(path string) (int, health.Report) {
+		res, err := http.Get(url + path)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var body struct {
+			Data health.Report `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return res.StatusCode, body.Data
+	}
+
+	statusCode, report := get("/healthz")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, health.StatusUp, report.Status)
+
+	statusCode, report = get("/readyz")
+	assert.Equal(t, http.StatusOK, statusCode)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "server", report.Checks[0].Name)
+	assert.Equal(t, "collector", report.Checks[1].Name)
+
+	statusCode, report = get("/health/dependencies")
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Equal(t, health.StatusDown, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, health.StatusUp, report.Checks[0].Status)
+	assert.Equal(t, "responded with status 401", report.Checks[1].Error)
+
+	require.NoError(t, webserver.Shutdown(context.Background()))
+	require.NoError(t, <-served)
+}